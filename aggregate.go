@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+type Aggregate struct {
+	fn     string
+	column string
+	alias  string
+}
+
+func CountOf(alias string) Aggregate       { return Aggregate{fn: "COUNT", alias: alias} }
+func SumOf(column, alias string) Aggregate { return Aggregate{fn: "SUM", column: column, alias: alias} }
+func AvgOf(column, alias string) Aggregate { return Aggregate{fn: "AVG", column: column, alias: alias} }
+func MinOf(column, alias string) Aggregate { return Aggregate{fn: "MIN", column: column, alias: alias} }
+func MaxOf(column, alias string) Aggregate { return Aggregate{fn: "MAX", column: column, alias: alias} }
+
+func (a Aggregate) sql(d Dialect) string {
+	if a.column == "" {
+		return a.fn + "(*)"
+	}
+	return a.fn + "(" + quoteIdent(d, a.column) + ")"
+}
+
+type aggregateQuery struct {
+	groupBy    []string
+	aggregates []Aggregate
+	having     Spec
+	orders     []orderClause
+}
+
+func (a aggregateQuery) columns() []string {
+	cols := slices.Clone(a.groupBy)
+	for _, agg := range a.aggregates {
+		cols = append(cols, agg.alias)
+	}
+	return cols
+}
+
+func (a aggregateQuery) validate() error {
+	if len(a.aggregates) == 0 && len(a.groupBy) == 0 {
+		return fmt.Errorf("%w: no aggregates", ErrInvalidSpec)
+	}
+	cols := a.columns()
+	for i, col := range cols {
+		if !isIdent(col) || slices.Contains(cols[:i], col) {
+			return fmt.Errorf("%w: result column %q", ErrInvalidSpec, col)
+		}
+	}
+	for _, agg := range a.aggregates {
+		if agg.column == "" && agg.fn != "COUNT" {
+			return fmt.Errorf("%w: %s without column", ErrInvalidSpec, agg.fn)
+		}
+		if agg.column != "" {
+			if err := checkIdent("column", agg.column); err != nil {
+				return err
+			}
+		}
+	}
+	if err := Validate(a.having); err != nil {
+		return err
+	}
+	for _, col := range Columns(a.having) {
+		if !slices.Contains(cols, col) {
+			return fmt.Errorf("%w: %q is not a group column or aggregate", ErrUnknownColumn, col)
+		}
+	}
+	for _, o := range a.orders {
+		if !slices.Contains(cols, o.column) {
+			return fmt.Errorf("%w: %q is not a group column or aggregate", ErrUnknownColumn, o.column)
+		}
+	}
+	return nil
+}
+
+type GroupQuery[T any] struct {
+	query *Query[T]
+	agg   aggregateQuery
+}
+
+func (q *Query[T]) GroupBy(columns ...string) *GroupQuery[T] {
+	return &GroupQuery[T]{query: q.Clone(), agg: aggregateQuery{groupBy: slices.Clone(columns)}}
+}
+
+func (g *GroupQuery[T]) clone() *GroupQuery[T] {
+	c := *g
+	c.agg.aggregates = slices.Clone(g.agg.aggregates)
+	c.agg.orders = slices.Clone(g.agg.orders)
+	return &c
+}
+
+func (g *GroupQuery[T]) Aggregate(aggs ...Aggregate) *GroupQuery[T] {
+	c := g.clone()
+	c.agg.aggregates = append(c.agg.aggregates, aggs...)
+	return c
+}
+
+func (g *GroupQuery[T]) Having(s Spec) *GroupQuery[T] {
+	c := g.clone()
+	switch {
+	case s == nil:
+	case c.agg.having == nil:
+		c.agg.having = s
+	default:
+		c.agg.having = And(c.agg.having, s)
+	}
+	return c
+}
+
+func (g *GroupQuery[T]) OrderBy(column string, dir Direction, nulls ...Nulls) *GroupQuery[T] {
+	c := g.clone()
+	c.agg.orders = append(c.agg.orders, orderClause{column: column, dir: dir, nulls: firstNulls(nulls)})
+	return c
+}
+
+func (g *GroupQuery[T]) All() ([]map[string]any, error) { return g.AllContext(g.query.context()) }
+
+func (g *GroupQuery[T]) AllContext(ctx context.Context) ([]map[string]any, error) {
+	rows, err := g.rows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cols := g.agg.columns()
+	result := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		m := make(map[string]any, len(cols))
+		for j, col := range cols {
+			if b, ok := row[j].([]byte); ok {
+				m[col] = string(b)
+			} else {
+				m[col] = row[j]
+			}
+		}
+		result[i] = m
+	}
+	return result, nil
+}
+
+func ScanGroups[T, R any](g *GroupQuery[T], scan func(Scanner) (R, error)) ([]R, error) {
+	return ScanGroupsContext(g.query.context(), g, scan)
+}
+
+func ScanGroupsContext[T, R any](ctx context.Context, g *GroupQuery[T], scan func(Scanner) (R, error)) ([]R, error) {
+	rows, err := g.rows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]R, len(rows))
+	for i, row := range rows {
+		if result[i], err = scan(&valuesScanner{values: row}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (g *GroupQuery[T]) rows(ctx context.Context) ([][]any, error) {
+	if err := g.agg.validate(); err != nil {
+		return nil, err
+	}
+	spec, _, err := g.query.prepare()
+	if err != nil {
+		return nil, err
+	}
+	agg := g.agg
+	if agg.orders, err = resolveOrders(agg.orders, nil, g.query.src.queryDialect()); err != nil {
+		return nil, err
+	}
+	return g.query.src.aggregate(ctx, spec, agg)
+}
+
+func Sum[T, V any](q *Query[T], column string) (V, error) {
+	return SumContext[T, V](q.context(), q, column)
+}
+
+func SumContext[T, V any](ctx context.Context, q *Query[T], column string) (V, error) {
+	return aggregateValue[T, V](ctx, q, SumOf(column, "value"))
+}
+
+func Avg[T, V any](q *Query[T], column string) (V, error) {
+	return AvgContext[T, V](q.context(), q, column)
+}
+
+func AvgContext[T, V any](ctx context.Context, q *Query[T], column string) (V, error) {
+	return aggregateValue[T, V](ctx, q, AvgOf(column, "value"))
+}
+
+func Min[T, V any](q *Query[T], column string) (V, error) {
+	return MinContext[T, V](q.context(), q, column)
+}
+
+func MinContext[T, V any](ctx context.Context, q *Query[T], column string) (V, error) {
+	return aggregateValue[T, V](ctx, q, MinOf(column, "value"))
+}
+
+func Max[T, V any](q *Query[T], column string) (V, error) {
+	return MaxContext[T, V](q.context(), q, column)
+}
+
+func MaxContext[T, V any](ctx context.Context, q *Query[T], column string) (V, error) {
+	return aggregateValue[T, V](ctx, q, MaxOf(column, "value"))
+}
+
+func aggregateValue[T, V any](ctx context.Context, q *Query[T], a Aggregate) (V, error) {
+	var value V
+	rows, err := (&GroupQuery[T]{query: q, agg: aggregateQuery{aggregates: []Aggregate{a}}}).rows(ctx)
+	if err != nil || len(rows) == 0 {
+		return value, err
+	}
+	if err := convertAssign(&value, rows[0][0]); err != nil {
+		return value, fmt.Errorf("%s(%s): %w", a.fn, a.column, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAggregateSQL(t *testing.T) {
+	t.Parallel()
+	tbl := Table{Name: "orders", PrimaryKey: []string{"id"}, Columns: []string{"id", "status", "amount"},
+		SoftDelete: "deleted_at"}
+	repo := newSimpleTestRepo(t, &testConn{}, tbl)
+
+	query, args, err := repo.aggregateSQL(Eq("status", "paid"), aggregateQuery{
+		aggregates: []Aggregate{SumOf("amount", "value")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `SELECT SUM("amount") AS "value" FROM "orders" WHERE ("deleted_at" IS NULL) AND ("status" = $1)`
+	if query != want || !reflect.DeepEqual(args, []any{"paid"}) {
+		t.Errorf("got %s %v", query, args)
+	}
+
+	query, args, err = repo.aggregateSQL(Eq("status", "paid"), aggregateQuery{
+		groupBy:    []string{"status"},
+		aggregates: []Aggregate{CountOf("n"), MaxOf("amount", "top")},
+		having:     Gt("n", 10),
+		orders:     []orderClause{{column: "top", dir: Desc}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = `SELECT * FROM (SELECT "status" AS "status", COUNT(*) AS "n", MAX("amount") AS "top" FROM "orders"` +
+		` WHERE ("deleted_at" IS NULL) AND ("status" = $1) GROUP BY "status") AS "grouped"` +
+		` WHERE "n" > $2 ORDER BY "top" DESC`
+	if query != want || !reflect.DeepEqual(args, []any{"paid", 10}) {
+		t.Errorf("got %s %v", query, args)
+	}
+
+	_, _, err = repo.WithColumnWhitelist(ColumnWhitelist{}).aggregateSQL(nil, aggregateQuery{
+		aggregates: []Aggregate{SumOf("secret", "value")},
+	})
+	if !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestRepository_Sum(t *testing.T) {
+	t.Parallel()
+	conn := &testConn{queries: []testQueryResult{
+		{columns: []string{"value"}, rows: [][]sqlDriver.Value{{[]byte("12.5")}}},
+		{columns: []string{"value"}, rows: [][]sqlDriver.Value{{nil}}},
+	}}
+	repo := newSimpleTestRepo(t, conn, simpleTable)
+	ctx := context.Background()
+
+	if sum, err := Sum[string, float64](repo.Query(ctx), "id"); err != nil || sum != 12.5 {
+		t.Errorf("got %v, %v", sum, err)
+	}
+	if sum, err := Sum[string, *float64](repo.Query(ctx), "id"); err != nil || sum != nil {
+		t.Errorf("expected nil for an empty set, got %v, %v", sum, err)
+	}
+}
+
+func TestRepository_TerminalsHonourContext(t *testing.T) {
+	t.Parallel()
+	repo := newSimpleTestRepo(t, &testConn{}, simpleTable)
+	q := repo.Query(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"SumContext": func() error { _, err := SumContext[string, int64](ctx, q, "id"); return err },
+		"AvgContext": func() error { _, err := AvgContext[string, float64](ctx, q, "id"); return err },
+		"MinContext": func() error { _, err := MinContext[string, string](ctx, q, "id"); return err },
+		"MaxContext": func() error { _, err := MaxContext[string, string](ctx, q, "id"); return err },
+		"SelectContext": func() error {
+			_, err := SelectContext(ctx, q, []string{"id"}, simpleScan)
+			return err
+		},
+		"PluckContext": func() error { _, err := PluckContext[string, string](ctx, q, "id"); return err },
+		"AllContext":   func() error { _, err := q.GroupBy("id").AllContext(ctx); return err },
+		"ScanGroupsContext": func() error {
+			_, err := ScanGroupsContext(ctx, q.GroupBy("id"), simpleScan)
+			return err
+		},
+		"CountContext": func() error { _, err := q.CountContext(ctx); return err },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+	}
+}
+
+func TestMemory_Aggregates(t *testing.T) {
+	t.Parallel()
+	m := newMemUsers(t,
+		memUser{ID: 1, Name: "a", Age: 30},
+		memUser{ID: 2, Name: "b", Age: 20},
+		memUser{ID: 3, Name: "c", Age: 40},
+	)
+	ctx := context.Background()
+	if err := m.Delete(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if sum, err := Sum[memUser, int](m.Query(ctx), "age"); err != nil || sum != 50 {
+		t.Errorf("Sum: got %v, %v", sum, err)
+	}
+	if avg, err := Avg[memUser, float64](m.Query(ctx), "age"); err != nil || avg != 25 {
+		t.Errorf("Avg: got %v, %v", avg, err)
+	}
+	if name, err := Min[memUser, string](m.Query(ctx), "name"); err != nil || name != "a" {
+		t.Errorf("Min: got %v, %v", name, err)
+	}
+	if age, err := Max[memUser, int64](m.Query(ctx).Where(Lt("age", 30)), "age"); err != nil || age != 20 {
+		t.Errorf("Max: got %v, %v", age, err)
+	}
+	if age, err := Max[memUser, *int](m.Query(ctx).Where(Gt("age", 100)), "age"); err != nil || age != nil {
+		t.Errorf("Max of empty set: got %v, %v", age, err)
+	}
+	if _, err := Sum[memUser, int](m.Query(ctx), "missing"); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestMemory_GroupBy(t *testing.T) {
+	t.Parallel()
+	m := newMemUsers(t,
+		memUser{ID: 1, Name: "a", Age: 30},
+		memUser{ID: 2, Name: "b", Age: 20},
+		memUser{ID: 3, Name: "c", Age: 30},
+		memUser{ID: 4, Name: "d", Age: 10},
+		memUser{ID: 5, Name: "e", Age: 20},
+	)
+	ctx := context.Background()
+
+	rows, err := m.Query(ctx).Where(Gt("age", 10)).
+		GroupBy("age").
+		Aggregate(CountOf("n"), MaxOf("name", "last")).
+		OrderBy("age", Desc).
+		All()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []map[string]any{
+		{"age": int64(30), "n": int64(2), "last": "c"},
+		{"age": int64(20), "n": int64(2), "last": "e"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v", rows)
+	}
+
+	type ageStat struct {
+		Age   int
+		Total int64
+	}
+	stats, err := ScanGroups(m.Query(ctx).GroupBy("age").Aggregate(SumOf("id", "total")).
+		Having(Gte("total", 5)).OrderBy("total", Asc),
+		func(sc Scanner) (ageStat, error) {
+			var s ageStat
+			return s, sc.Scan(&s.Age, &s.Total)
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(stats, []ageStat{{20, 7}}) {
+		t.Errorf("got %v", stats)
+	}
+
+	for name, g := range map[string]*GroupQuery[memUser]{
+		"having": m.Query(ctx).GroupBy("age").Aggregate(CountOf("n")).Having(Gt("name", "a")),
+		"order":  m.Query(ctx).GroupBy("age").OrderBy("id", Asc),
+	} {
+		if _, err := g.All(); !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("%s: expected ErrUnknownColumn, got %v", name, err)
+		}
+	}
+	if _, err := m.Query(ctx).GroupBy("age").Aggregate(SumOf("id", "age")).All(); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("duplicate result column: expected ErrInvalidSpec, got %v", err)
+	}
+}
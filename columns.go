@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type ColumnWhitelist struct {
+	Columns []string
+	Aliases map[string]string
+	Tables  map[string][]string
+}
+
+type columnResolver struct {
+	table   string
+	allowed map[string]bool
+	aliases map[string]string
+	tables  map[string]map[string]bool
+}
+
+type subqueryScope struct {
+	table   string
+	columns map[string]bool
+}
+
+func newColumnResolver(t Table, relations []Relation, w ColumnWhitelist) *columnResolver {
+	allowed := makeSet(t.Columns)
+	for _, c := range []string{t.CreatedAt, t.UpdatedAt} {
+		if c != "" {
+			allowed[c] = true
+		}
+	}
+	for _, c := range w.Columns {
+		allowed[c] = true
+	}
+	aliases := make(map[string]string, len(w.Aliases))
+	for k, v := range w.Aliases {
+		aliases[k] = v
+	}
+	tables := make(map[string]map[string]bool)
+	addTable := func(name string, cols ...string) {
+		if tables[name] == nil {
+			tables[name] = make(map[string]bool)
+		}
+		for _, c := range cols {
+			if c != "" {
+				tables[name][c] = true
+			}
+		}
+	}
+	for _, rel := range relations {
+		addTable(rel.Table, rel.Columns...)
+		addTable(rel.Table, rel.ForeignKey, rel.PrimaryKey)
+	}
+	for name, cols := range w.Tables {
+		addTable(name, cols...)
+	}
+	return &columnResolver{table: t.Name, allowed: allowed, aliases: aliases, tables: tables}
+}
+
+func (c *columnResolver) resolve(name string) (string, error) {
+	if target, ok := c.aliases[name]; ok {
+		return target, nil
+	}
+	if c.allowed[name] {
+		return name, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+}
+
+func (c *columnResolver) check(s Spec) error {
+	return c.checkScoped(s, nil)
+}
+
+func (c *columnResolver) checkScoped(s Spec, scopes []subqueryScope) error {
+	var err error
+	Walk(s, func(n Node) bool {
+		switch n.Kind {
+		case CustomNode:
+			err = fmt.Errorf("%w: %T cannot be checked against the column whitelist", ErrUnsupportedSpec, n.Spec)
+		case ExistsNode, InSelectNode:
+			err = c.checkSubquery(n, scopes)
+			return false
+		default:
+			if len(scopes) > 0 {
+				err = c.checkInScope(nodeColumns(n), scopes)
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+func (c *columnResolver) checkSubquery(n Node, scopes []subqueryScope) error {
+	cols, ok := c.tables[n.Table]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTable, n.Table)
+	}
+	inner := append(slices.Clone(scopes), subqueryScope{table: n.Table, columns: cols})
+	if n.Kind == InSelectNode {
+		if len(scopes) > 0 {
+			if err := c.checkInScope([]string{n.Column}, scopes); err != nil {
+				return err
+			}
+		}
+		if err := c.checkInScope([]string{n.SelectColumn}, inner); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.Children {
+		if err := c.checkScoped(child, inner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *columnResolver) checkInScope(cols []string, scopes []subqueryScope) error {
+	for _, col := range cols {
+		if !c.inScope(col, scopes) {
+			return fmt.Errorf("%w: %q", ErrUnknownColumn, col)
+		}
+	}
+	return nil
+}
+
+func (c *columnResolver) inScope(col string, scopes []subqueryScope) bool {
+	table, name, qualified := strings.Cut(col, ".")
+	if !qualified {
+		return scopes[len(scopes)-1].columns[col]
+	}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if scopes[i].table == table {
+			return scopes[i].columns[name]
+		}
+	}
+	return table == c.table && c.allowed[name]
+}
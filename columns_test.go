@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"testing"
+)
+
+var whitelistTable = Table{
+	Name:       "users",
+	PrimaryKey: []string{"id"},
+	Columns:    []string{"id", "name"},
+	CreatedAt:  "created_at",
+}
+
+func TestColumnResolver_Resolve(t *testing.T) {
+	t.Parallel()
+	r := newColumnResolver(whitelistTable, nil, ColumnWhitelist{
+		Columns: []string{"score"},
+		Aliases: map[string]string{"created": "created_at"},
+	})
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"name", "name", true},
+		{"created_at", "created_at", true},
+		{"score", "score", true},
+		{"created", "created_at", true},
+		{"password", "", false},
+		{"lower(name)", "", false},
+	}
+	for _, tt := range tests {
+		got, err := r.resolve(tt.in)
+		if tt.ok && (err != nil || got != tt.want) {
+			t.Errorf("resolve(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+		if !tt.ok && !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("resolve(%q): expected ErrUnknownColumn, got %v", tt.in, err)
+		}
+	}
+}
+
+func TestMapSpecColumns_RewritesNestedSpecs(t *testing.T) {
+	t.Parallel()
+	spec := And(
+		Eq("a", 1), In("b", 1, 2), Like("c", "x%"),
+		Or(Between("d", 1, 2), Not(IsNull("e"))),
+		Raw("raw_col > $1", 5),
+	)
+	mapped, err := MapColumns(spec, func(c string) (string, error) { return "t_" + c, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, _, _ := mapped.ToSQL(Postgres(), 1)
+	want := `("t_a" = $1) AND ("t_b" IN ($2, $3)) AND ("t_c" LIKE $4) AND ` +
+		`(("t_d" BETWEEN $5 AND $6) OR (NOT ("t_e" IS NULL))) AND (raw_col > $7)`
+	if sql != want {
+		t.Errorf("expected %q, got %q", want, sql)
+	}
+}
+
+func TestMapSpecColumns_PropagatesError(t *testing.T) {
+	t.Parallel()
+	fail := func(string) (string, error) { return "", ErrUnknownColumn }
+	specs := []Spec{
+		Eq("a", 1), In("a"), ILike("a", "x"), Between("a", 1, 2), IsNotNull("a"),
+		And(Eq("a", 1)), Or(Eq("a", 1)), Not(Eq("a", 1)),
+	}
+	for _, s := range specs {
+		if _, err := MapColumns(s, fail); !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("%T: expected error, got %v", s, err)
+		}
+	}
+}
+
+func TestRepository_WithColumnWhitelist_RejectsUnknown(t *testing.T) {
+	t.Parallel()
+	conn := &testConn{}
+	repo := newSimpleTestRepo(t, conn, whitelistTable).WithColumnWhitelist(ColumnWhitelist{})
+	ctx := context.Background()
+
+	if _, err := repo.FindBy(ctx, Eq("password", "x")); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("FindBy: expected ErrUnknownColumn, got %v", err)
+	}
+	if _, err := repo.CountBy(ctx, Eq("password", "x")); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("CountBy: expected ErrUnknownColumn, got %v", err)
+	}
+	if _, err := repo.ExistsBy(ctx, Eq("password", "x")); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("ExistsBy: expected ErrUnknownColumn, got %v", err)
+	}
+	if _, err := repo.Query(ctx).OrderBy("password", Asc).All(); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("OrderBy: expected ErrUnknownColumn, got %v", err)
+	}
+	if _, err := repo.Query(ctx).Where(Eq("password", "x")).Count(); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("Count: expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestRepository_WithColumnWhitelist_AllowsKnown(t *testing.T) {
+	t.Parallel()
+	conn := &testConn{queries: []testQueryResult{
+		{columns: []string{"id"}, rows: [][]sqlDriver.Value{{"a"}}},
+	}}
+	repo := newSimpleTestRepo(t, conn, whitelistTable).WithColumnWhitelist(ColumnWhitelist{})
+	items, err := repo.FindBy(context.Background(), Eq("name", "alice"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("expected 1 item, got %d", len(items))
+	}
+}
+
+func TestQuery_Prepare_ResolvesAliases(t *testing.T) {
+	t.Parallel()
+	r := (&Repository[string]{table: whitelistTable, dialect: Postgres()}).
+		WithColumnWhitelist(ColumnWhitelist{Aliases: map[string]string{"created": "created_at"}})
+	sort, err := ParseSort("-created,name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := r.Query(context.Background()).Where(Gt("created", "2024-01-01")).Sort(sort)
+
+	spec, orders, err := q.prepare()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql, _, _ := spec.ToSQL(Postgres(), 1); sql != `"created_at" > $1` {
+		t.Errorf("unexpected spec SQL: %q", sql)
+	}
+	if got := buildOrderSQL(Postgres(), orders); got != ` ORDER BY "created_at" DESC, "name" ASC` {
+		t.Errorf("unexpected order SQL: %q", got)
+	}
+}
+
+func TestQuery_InvalidDirection(t *testing.T) {
+	t.Parallel()
+	conn := &testConn{}
+	repo := newSimpleTestRepo(t, conn, simpleTable)
+	_, err := repo.Query(context.Background()).OrderBy("id", Direction("ASC; DROP TABLE items")).All()
+	if !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("expected ErrInvalidDirection, got %v", err)
+	}
+}
+
+func TestRepository_RejectsNonIdentifierColumns(t *testing.T) {
+	t.Parallel()
+	repo := newSimpleTestRepo(t, &testConn{}, simpleTable)
+	ctx := context.Background()
+
+	specs := []Spec{
+		Eq("id; DROP TABLE items", 1),
+		Or(IsNull("lower(name)")),
+		GtCol("id", "1=1"),
+		Exists("items i", nil),
+		InSelect("id", "items", "id) OR (1", nil),
+	}
+	for _, s := range specs {
+		if _, err := repo.FindBy(ctx, s); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%T: expected ErrInvalidSpec, got %v", s, err)
+		}
+	}
+	if _, err := repo.Query(ctx).OrderBy("lower(name)", Asc).All(); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("OrderBy: expected ErrInvalidSpec, got %v", err)
+	}
+	if _, err := Pluck[string, string](repo.Query(ctx), "count(*)"); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("Pluck: expected ErrInvalidSpec, got %v", err)
+	}
+	if _, err := Sum[string, int64](repo.Query(ctx), "a+b"); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("Sum: expected ErrInvalidSpec, got %v", err)
+	}
+}
+
+func TestRepository_ExpressionsNeedExplicitOptIn(t *testing.T) {
+	t.Parallel()
+	conn := &testConn{queries: []testQueryResult{{columns: []string{"id"}}}}
+	repo := newSimpleTestRepo(t, conn, simpleTable)
+	_, err := repo.Query(context.Background()).
+		Where(EqExpr(Expr("lower(id)"), "a")).
+		OrderByExpr(Expr("length(id)"), Asc).
+		All()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRepository_WithColumnWhitelist_ChecksSubqueries(t *testing.T) {
+	t.Parallel()
+	r := (&Repository[string]{table: whitelistTable, dialect: Postgres()}).
+		WithColumnWhitelist(ColumnWhitelist{
+			Aliases: map[string]string{"user": "name"},
+			Tables:  map[string][]string{"orders": {"user_id", "total"}},
+		})
+	spec, err := r.prepareSpec(And(
+		Eq("user", "a"),
+		InSelect("id", "orders", "user_id", Gt("total", 1)),
+		Exists("orders", EqCol("orders.user_id", "users.id")),
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, _, _ := spec.ToSQL(Postgres(), 1)
+	want := `("name" = $1) AND ("id" IN (SELECT "user_id" FROM "orders" WHERE "total" > $2)) AND ` +
+		`(EXISTS (SELECT 1 FROM "orders" WHERE "orders"."user_id" = "users"."id"))`
+	if sql != want {
+		t.Errorf("got %s", sql)
+	}
+	rejected := []struct {
+		spec Spec
+		err  error
+	}{
+		{InSelect("password", "orders", "user_id", nil), ErrUnknownColumn},
+		{InSelect("id", "orders", "secret", nil), ErrUnknownColumn},
+		{Exists("orders", Eq("secret", 1)), ErrUnknownColumn},
+		{Exists("orders", EqCol("orders.user_id", "users.password")), ErrUnknownColumn},
+		{Exists("orders", Exists("payments", nil)), ErrUnknownTable},
+		{Exists("sessions", nil), ErrUnknownTable},
+		{Not(&customSpec{}), ErrUnsupportedSpec},
+	}
+	for _, tt := range rejected {
+		if _, err := r.prepareSpec(tt.spec); !errors.Is(err, tt.err) {
+			t.Errorf("%T: expected %v, got %v", tt.spec, tt.err, err)
+		}
+	}
+}
+
+func TestRepository_WithColumnWhitelist_AllowsRelationTables(t *testing.T) {
+	t.Parallel()
+	r := (&Repository[string]{table: whitelistTable, dialect: Postgres(), relations: []Relation{itemsRelation}}).
+		WithColumnWhitelist(ColumnWhitelist{})
+	if _, err := r.prepareSpec(HasChild("items", Gt("value", 1))); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := r.prepareSpec(HasChild("items", Gt("secret", 1))); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strconv"
 	"time"
 )
 
@@ -80,6 +81,12 @@ func assignInt64(d *int64, src any) error {
 		*d = int64(s)
 	case float64:
 		*d = int64(s)
+	case string, []byte:
+		v, err := strconv.ParseInt(asText(s), 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to int64", s)
+		}
+		*d = v
 	default:
 		return fmt.Errorf("cannot convert %T to int64", src)
 	}
@@ -115,12 +122,25 @@ func assignFloat64(d *float64, src any) error {
 		*d = float64(s)
 	case float32:
 		*d = float64(s)
+	case string, []byte:
+		v, err := strconv.ParseFloat(asText(s), 64)
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to float64", s)
+		}
+		*d = v
 	default:
 		return fmt.Errorf("cannot convert %T to float64", src)
 	}
 	return nil
 }
 
+func asText(src any) string {
+	if b, ok := src.([]byte); ok {
+		return string(b)
+	}
+	return src.(string)
+}
+
 func assignFloat32(d *float32, src any) error {
 	switch s := src.(type) {
 	case float32:
@@ -180,6 +200,14 @@ func reflectAssign(dest, src any) error {
 		dv.Set(sv.Convert(dv.Type()))
 		return nil
 	}
+	if dv.Kind() == reflect.Pointer {
+		elem := reflect.New(dv.Type().Elem())
+		if err := convertAssign(elem.Interface(), src); err != nil {
+			return err
+		}
+		dv.Set(elem)
+		return nil
+	}
 	return fmt.Errorf("cannot convert %T to %s", src, dv.Type())
 }
 
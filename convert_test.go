@@ -357,6 +357,17 @@ func TestConvertAssign_ReflectConvertible(t *testing.T) {
 	}
 }
 
+func TestConvertAssign_PointerToPointer(t *testing.T) {
+	t.Parallel()
+	var d *int64
+	if err := convertAssign(&d, int64(42)); err != nil || d == nil || *d != 42 {
+		t.Errorf("unexpected: %v, %v", d, err)
+	}
+	if err := convertAssign(&d, "x"); err == nil {
+		t.Error("expected conversion error")
+	}
+}
+
 func TestReflectAssign_NonPointerDest(t *testing.T) {
 	t.Parallel()
 	err := reflectAssign(42, "hello")
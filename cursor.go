@@ -1,13 +1,26 @@
 package repository
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	sqlDriver "database/sql/driver"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const cursorVersion = 1
+
 type Cursor struct {
-	Values map[string]any `json:"v"`
+	Values      map[string]any
+	Offset      int64
+	Fingerprint string
 }
 
 type CursorExtractor[T any] func(T) map[string]any
@@ -15,26 +28,192 @@ type CursorExtractor[T any] func(T) map[string]any
 type Page[T any] struct {
 	Items      []T    `json:"items"`
 	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 	HasMore    bool   `json:"has_more"`
+	HasPrev    bool   `json:"has_prev"`
+}
+
+type cursorPayload struct {
+	Version     int                    `json:"ver"`
+	Values      map[string]cursorValue `json:"v,omitempty"`
+	Offset      int64                  `json:"o,omitempty"`
+	Fingerprint string                 `json:"f,omitempty"`
 }
 
-func EncodeCursor(c Cursor) string {
-	b, _ := json.Marshal(c)
-	return base64.URLEncoding.EncodeToString(b)
+type cursorValue struct {
+	Type  string `json:"t,omitempty"`
+	Value string `json:"v,omitempty"`
 }
 
-func DecodeCursor(s string) (Cursor, error) {
-	b, err := base64.URLEncoding.DecodeString(s)
+func EncodeCursor(c Cursor) string { return SignCursor(c, nil) }
+
+func DecodeCursor(s string) (Cursor, error) { return VerifyCursor(s, nil) }
+
+func SignCursor(c Cursor, key []byte) string {
+	payload := cursorPayload{Version: cursorVersion, Offset: c.Offset, Fingerprint: c.Fingerprint}
+	if len(c.Values) > 0 {
+		payload.Values = make(map[string]cursorValue, len(c.Values))
+		for col, v := range c.Values {
+			payload.Values[col] = encodeCursorValue(v)
+		}
+	}
+	b, _ := json.Marshal(payload)
+	token := base64.URLEncoding.EncodeToString(b)
+	if len(key) == 0 {
+		return token
+	}
+	return token + "." + base64.RawURLEncoding.EncodeToString(cursorMAC(token, key))
+}
+
+func VerifyCursor(s string, key []byte) (Cursor, error) {
+	token, sig, signed := strings.Cut(s, ".")
+	if len(key) > 0 {
+		mac, err := base64.RawURLEncoding.DecodeString(sig)
+		if !signed || err != nil || !hmac.Equal(mac, cursorMAC(token, key)) {
+			return Cursor{}, fmt.Errorf("%w: signature mismatch", ErrInvalidCursor)
+		}
+	}
+	b, err := base64.URLEncoding.DecodeString(token)
 	if err != nil {
 		return Cursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
 	}
-	var c Cursor
-	if err := json.Unmarshal(b, &c); err != nil {
+	var payload cursorPayload
+	if err := json.Unmarshal(b, &payload); err != nil {
 		return Cursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
 	}
+	if payload.Version != cursorVersion {
+		return Cursor{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidCursor, payload.Version)
+	}
+	c := Cursor{Offset: payload.Offset, Fingerprint: payload.Fingerprint}
+	if len(payload.Values) > 0 {
+		c.Values = make(map[string]any, len(payload.Values))
+		for col, v := range payload.Values {
+			if c.Values[col], err = decodeCursorValue(v); err != nil {
+				return Cursor{}, fmt.Errorf("%w: %q: %v", ErrInvalidCursor, col, err)
+			}
+		}
+	}
 	return c, nil
 }
 
+func cursorMAC(token string, key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(token))
+	return h.Sum(nil)
+}
+
+func encodeCursorValue(v any) cursorValue {
+	switch x := v.(type) {
+	case nil:
+		return cursorValue{}
+	case time.Time:
+		return cursorValue{"time", x.Format(time.RFC3339Nano)}
+	case []byte:
+		return cursorValue{"bytes", base64.StdEncoding.EncodeToString(x)}
+	case string:
+		return cursorValue{"string", x}
+	case bool:
+		return cursorValue{"bool", strconv.FormatBool(x)}
+	}
+	if valuer, ok := v.(sqlDriver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			return encodeCursorValue(value)
+		}
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Array && rv.Len() == 16 && rv.Type().Elem().Kind() == reflect.Uint8 {
+		var id [16]byte
+		reflect.Copy(reflect.ValueOf(&id).Elem(), rv)
+		return cursorValue{"uuid", formatUUID(id)}
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{"int", strconv.FormatInt(rv.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{"uint", strconv.FormatUint(rv.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{"float", strconv.FormatFloat(rv.Float(), 'g', -1, 64)}
+	case reflect.String:
+		return cursorValue{"string", rv.String()}
+	case reflect.Bool:
+		return cursorValue{"bool", strconv.FormatBool(rv.Bool())}
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return cursorValue{}
+		}
+		return encodeCursorValue(rv.Elem().Interface())
+	}
+	b, _ := json.Marshal(v)
+	return cursorValue{"json", string(b)}
+}
+
+func decodeCursorValue(v cursorValue) (any, error) {
+	switch v.Type {
+	case "":
+		return nil, nil
+	case "string":
+		return v.Value, nil
+	case "uuid":
+		id, err := parseUUID(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		return formatUUID(id), nil
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "int":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "uint":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(v.Value, 64)
+	case "json":
+		var value any
+		err := json.Unmarshal([]byte(v.Value), &value)
+		return value, err
+	}
+	return nil, fmt.Errorf("unknown value type %q", v.Type)
+}
+
+func formatUUID(id [16]byte) string {
+	h := hex.EncodeToString(id[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var id [16]byte
+	h := strings.ReplaceAll(s, "-", "")
+	if len(s) != 36 || len(h) != 32 {
+		return id, fmt.Errorf("invalid uuid %q", s)
+	}
+	if _, err := hex.Decode(id[:], []byte(h)); err != nil {
+		return id, fmt.Errorf("invalid uuid %q: %w", s, err)
+	}
+	return id, nil
+}
+
+func cursorFingerprint(d Dialect, s Spec, orders []orderClause) string {
+	var b strings.Builder
+	orderSQL, orderArgs, next := renderOrderSQL(d, orders, 1)
+	b.WriteString(orderSQL)
+	args := orderArgs
+	if s != nil {
+		sql, specArgs, _ := s.ToSQL(d, next)
+		b.WriteString(" WHERE " + sql)
+		args = append(args, specArgs...)
+	}
+	for _, a := range args {
+		v := encodeCursorValue(a)
+		b.WriteString("\x00" + v.Type + ":" + v.Value)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
 func buildKeysetSpec(orders []orderClause, values map[string]any, forward bool) Spec {
 	n := len(orders)
 	if n == 0 {
@@ -44,19 +223,15 @@ func buildKeysetSpec(orders []orderClause, values map[string]any, forward bool)
 	orParts := make([]Spec, 0, n)
 	for i := range n {
 		andParts := make([]Spec, 0, i+1)
-
 		for j := range i {
-			andParts = append(andParts, Eq(orders[j].column, values[orders[j].column]))
+			andParts = append(andParts, keysetEq(orders[j].column, values[orders[j].column]))
 		}
 
-		col := orders[i]
-		val := values[col.column]
-		useGt := (col.dir == Asc && forward) || (col.dir == Desc && !forward)
-		if useGt {
-			andParts = append(andParts, Gt(col.column, val))
-		} else {
-			andParts = append(andParts, Lt(col.column, val))
+		after := keysetAfter(orders[i], values[orders[i].column], forward)
+		if isConst(after, false) {
+			continue
 		}
+		andParts = append(andParts, after)
 
 		if len(andParts) == 1 {
 			orParts = append(orParts, andParts[0])
@@ -70,3 +245,62 @@ func buildKeysetSpec(orders []orderClause, values map[string]any, forward bool)
 	}
 	return Or(orParts...)
 }
+
+func keysetEq(column string, value any) Spec {
+	if value == nil {
+		return IsNull(column)
+	}
+	return Eq(column, value)
+}
+
+func keysetAfter(o orderClause, value any, forward bool) Spec {
+	nullsFirst := o.nullsFirst == forward
+	if value == nil {
+		if nullsFirst {
+			return IsNotNull(o.column)
+		}
+		return Or()
+	}
+	var cmp Spec
+	if (o.dir == Asc) == forward {
+		cmp = Gt(o.column, value)
+	} else {
+		cmp = Lt(o.column, value)
+	}
+	if nullsFirst || !o.nullable {
+		return cmp
+	}
+	return Or(cmp, IsNull(o.column))
+}
+
+func encodeCursorValues[T any](
+	encode func(T) CompositeValues, columns []string, item T, orders []orderClause,
+) (map[string]any, error) {
+	if encode == nil {
+		return nil, fmt.Errorf("%w: no mapping to derive cursor values, pass a CursorExtractor", ErrInvalidCursor)
+	}
+	root := encode(item).Root
+	values := make(map[string]any, len(orders))
+	for _, o := range orders {
+		i := slices.Index(columns, o.column)
+		if i < 0 || i >= len(root) {
+			return nil, fmt.Errorf("%w: cannot derive value for %q from the mapping, pass a CursorExtractor",
+				ErrInvalidCursor, o.column)
+		}
+		v, err := sqlDriver.DefaultParameterConverter.ConvertValue(root[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidCursor, o.column, err)
+		}
+		values[o.column] = v
+	}
+	return values, nil
+}
+
+func checkCursorValues(orders []orderClause, values map[string]any) error {
+	for _, o := range orders {
+		if _, ok := values[o.column]; !ok {
+			return fmt.Errorf("%w: missing value for %q", ErrInvalidCursor, o.column)
+		}
+	}
+	return nil
+}
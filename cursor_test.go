@@ -1,9 +1,14 @@
 package repository
 
 import (
+	"context"
+	sqlDriver "database/sql/driver"
 	"encoding/base64"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEncodeCursor_Basic(t *testing.T) {
@@ -51,6 +56,163 @@ func TestDecodeCursor_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestCursor_PreservesValueTypes(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2024, 3, 1, 10, 30, 0, 123456789, time.FixedZone("MSK", 3*3600))
+	id := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	decoded, err := DecodeCursor(EncodeCursor(Cursor{Values: map[string]any{
+		"at": at, "n": int64(1) << 60, "u": uint32(7), "f": 1.5, "b": []byte{0, 255},
+		"ok": true, "s": "x", "id": id, "null": nil,
+	}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"at": at, "n": int64(1) << 60, "u": uint64(7), "f": 1.5, "b": []byte{0, 255},
+		"ok": true, "s": "x", "id": "123e4567-e89b-12d3-a456-426614174000", "null": nil,
+	}
+	if got := decoded.Values["at"].(time.Time); !got.Equal(at) {
+		t.Errorf("time: got %v", got)
+	}
+	delete(want, "at")
+	delete(decoded.Values, "at")
+	if !reflect.DeepEqual(decoded.Values, want) {
+		t.Errorf("got %#v", decoded.Values)
+	}
+}
+
+func TestCursor_Signed(t *testing.T) {
+	t.Parallel()
+	key := []byte("secret")
+	signed := SignCursor(Cursor{Values: map[string]any{"id": int64(5)}}, key)
+	if c, err := VerifyCursor(signed, key); err != nil || c.Values["id"] != int64(5) {
+		t.Fatalf("unexpected: %v, %v", c, err)
+	}
+
+	forged := EncodeCursor(Cursor{Values: map[string]any{"id": int64(500)}})
+	tampered := forged + signed[strings.Index(signed, "."):]
+	for name, s := range map[string]string{
+		"unsigned": forged, "tampered": tampered, "other key": SignCursor(Cursor{}, []byte("other")),
+	} {
+		if _, err := VerifyCursor(s, key); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", name, err)
+		}
+	}
+}
+
+func TestDecodeCursor_UnsupportedVersion(t *testing.T) {
+	t.Parallel()
+	encoded := base64.URLEncoding.EncodeToString([]byte(`{"v":{"id":"a"}}`))
+	if _, err := DecodeCursor(encoded); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestQuery_Page_RejectsCursorOfAnotherQuery(t *testing.T) {
+	t.Parallel()
+	m := newMemUsers(t, memUser{ID: 1, Name: "a"}, memUser{ID: 2, Name: "b"}, memUser{ID: 3, Name: "c"})
+	ctx := context.Background()
+	first, err := m.Query(ctx).Where(Gt("id", 0)).OrderBy("name", Asc).PageSize(1).Page()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := m.Query(ctx).Where(Gt("id", 0)).OrderBy("name", Asc).PageSize(1).
+		After(first.NextCursor).Page(); err != nil {
+		t.Errorf("same query must accept its cursor, got %v", err)
+	}
+	for name, q := range map[string]*Query[memUser]{
+		"order":  m.Query(ctx).Where(Gt("id", 0)).OrderBy("name", Desc),
+		"filter": m.Query(ctx).Where(Gt("id", 1)).OrderBy("name", Asc),
+	} {
+		if _, err := q.PageSize(1).After(first.NextCursor).Page(); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", name, err)
+		}
+	}
+
+	stripped, err := DecodeCursor(first.NextCursor)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stripped.Fingerprint = ""
+	if _, err := m.Query(ctx).Where(Gt("id", 0)).OrderBy("name", Asc).PageSize(1).
+		After(EncodeCursor(stripped)).Page(); err != nil {
+		t.Errorf("unsigned cursor without fingerprint must be accepted, got %v", err)
+	}
+}
+
+type testUUID [16]byte
+
+func (id testUUID) Value() (sqlDriver.Value, error) { return formatUUID(id), nil }
+
+func TestQuery_Page_UUIDKeyRoundTrip(t *testing.T) {
+	t.Parallel()
+	type item struct {
+		ID   testUUID
+		Name string
+	}
+	m := NewMemory(Simple(SimpleConfig[item]{
+		Table: Table{Name: "items", PrimaryKey: []string{"id"}, Columns: []string{"id", "name"}},
+		Scan: func(sc Scanner) (item, error) {
+			var it item
+			var id string
+			err := sc.Scan(&id, &it.Name)
+			if err == nil {
+				var raw [16]byte
+				raw, err = parseUUID(id)
+				it.ID = raw
+			}
+			return it, err
+		},
+		Values: func(it item) []any { return []any{it.ID, it.Name} },
+	}))
+	ctx := context.Background()
+	for i := byte(1); i <= 3; i++ {
+		if err := m.Save(ctx, item{ID: testUUID{15: i}, Name: string('a' + rune(i))}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	extract := func(it item) map[string]any { return map[string]any{"id": it.ID} }
+
+	first, err := m.Query(ctx).PageSize(2).Page(extract)
+	if err != nil || len(first.Items) != 2 || !first.HasMore {
+		t.Fatalf("unexpected first page: %+v, %v", first, err)
+	}
+	cur, err := DecodeCursor(first.NextCursor)
+	if err != nil || cur.Values["id"] != formatUUID([16]byte{15: 2}) {
+		t.Fatalf("uuid must decode to its canonical string, got %#v, %v", cur.Values["id"], err)
+	}
+	next, err := m.Query(ctx).PageSize(2).After(first.NextCursor).Page(extract)
+	if err != nil || len(next.Items) != 1 || next.Items[0].ID != (testUUID{15: 3}) {
+		t.Errorf("unexpected second page: %+v, %v", next, err)
+	}
+}
+
+func TestRepository_WithCursorKey_SignsPages(t *testing.T) {
+	t.Parallel()
+	conn := &testConn{queries: []testQueryResult{
+		{columns: []string{"id"}, rows: [][]sqlDriver.Value{{"a"}, {"b"}}},
+	}}
+	repo := newSimpleTestRepo(t, conn, simpleTable).WithCursorKey([]byte("k"))
+	page, err := repo.Query(context.Background()).PageSize(1).Page()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := VerifyCursor(page.NextCursor, []byte("k")); err != nil {
+		t.Errorf("expected signed cursor, got %v", err)
+	}
+	unsigned := EncodeCursor(Cursor{Values: map[string]any{"id": "a"}})
+	_, err = repo.Query(context.Background()).PageSize(1).After(unsigned).Page()
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for unsigned cursor, got %v", err)
+	}
+	handBuilt := SignCursor(Cursor{Values: map[string]any{"id": "a"}}, []byte("k"))
+	_, err = repo.Query(context.Background()).PageSize(1).After(handBuilt).Page()
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for signed cursor without fingerprint, got %v", err)
+	}
+}
+
 func TestBuildKeysetSpec_Empty(t *testing.T) {
 	t.Parallel()
 	result := buildKeysetSpec(nil, nil, true)
@@ -68,8 +230,8 @@ func TestBuildKeysetSpec_SingleAscForward(t *testing.T) {
 		t.Fatal("expected non-nil spec")
 	}
 	sql, args, _ := spec.ToSQL(Postgres(), 1)
-	if sql != "id > $1" {
-		t.Errorf("expected 'id > $1', got %q", sql)
+	if sql != `"id" > $1` {
+		t.Errorf("expected '\"id\" > $1', got %q", sql)
 	}
 	if len(args) != 1 || args[0] != "100" {
 		t.Errorf("unexpected args: %v", args)
@@ -82,8 +244,8 @@ func TestBuildKeysetSpec_SingleDescForward(t *testing.T) {
 	vals := map[string]any{"id": "100"}
 	spec := buildKeysetSpec(orders, vals, true)
 	sql, _, _ := spec.ToSQL(Postgres(), 1)
-	if sql != "id < $1" {
-		t.Errorf("expected 'id < $1', got %q", sql)
+	if sql != `"id" < $1` {
+		t.Errorf("expected '\"id\" < $1', got %q", sql)
 	}
 }
 
@@ -93,8 +255,8 @@ func TestBuildKeysetSpec_SingleAscBackward(t *testing.T) {
 	vals := map[string]any{"id": "100"}
 	spec := buildKeysetSpec(orders, vals, false)
 	sql, _, _ := spec.ToSQL(Postgres(), 1)
-	if sql != "id < $1" {
-		t.Errorf("expected 'id < $1', got %q", sql)
+	if sql != `"id" < $1` {
+		t.Errorf("expected '\"id\" < $1', got %q", sql)
 	}
 }
 
@@ -104,8 +266,8 @@ func TestBuildKeysetSpec_SingleDescBackward(t *testing.T) {
 	vals := map[string]any{"id": "100"}
 	spec := buildKeysetSpec(orders, vals, false)
 	sql, _, _ := spec.ToSQL(Postgres(), 1)
-	if sql != "id > $1" {
-		t.Errorf("expected 'id > $1', got %q", sql)
+	if sql != `"id" > $1` {
+		t.Errorf("expected '\"id\" > $1', got %q", sql)
 	}
 }
 
@@ -128,3 +290,96 @@ func TestBuildKeysetSpec_MultipleOrders(t *testing.T) {
 		t.Error("expected non-empty SQL")
 	}
 }
+
+func TestBuildKeysetSpec_NullableColumn(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		order   orderClause
+		value   any
+		forward bool
+		want    string
+	}{
+		{orderClause{column: "due", dir: Asc, nullable: true}, "d", true, `("due" > $1) OR ("due" IS NULL)`},
+		{orderClause{column: "due", dir: Asc, nullable: true}, nil, true, `FALSE`},
+		{orderClause{column: "due", dir: Asc, nullable: true}, nil, false, `"due" IS NOT NULL`},
+		{orderClause{column: "due", dir: Asc, nullable: true}, "d", false, `"due" < $1`},
+		{orderClause{column: "due", dir: Desc, nullable: true, nullsFirst: true}, "d", true, `"due" < $1`},
+		{orderClause{column: "due", dir: Desc, nullable: true, nullsFirst: true}, nil, true, `"due" IS NOT NULL`},
+		{orderClause{column: "due", dir: Desc, nullable: true, nullsFirst: true}, "d", false,
+			`("due" > $1) OR ("due" IS NULL)`},
+	}
+	for _, tt := range tests {
+		spec := buildKeysetSpec([]orderClause{tt.order}, map[string]any{"due": tt.value}, tt.forward)
+		if sql, _, _ := spec.ToSQL(Postgres(), 1); sql != tt.want {
+			t.Errorf("%+v %v %v: expected %s, got %s", tt.order, tt.value, tt.forward, tt.want, sql)
+		}
+	}
+
+	orders := []orderClause{{column: "due", dir: Asc, nullable: true}, {column: "id", dir: Asc}}
+	spec := buildKeysetSpec(orders, map[string]any{"due": nil, "id": 7}, true)
+	if sql, _, _ := spec.ToSQL(Postgres(), 1); sql != `("due" IS NULL) AND ("id" > $1)` {
+		t.Errorf("got %s", sql)
+	}
+}
+
+func TestQuery_Page_MissingCursorValue(t *testing.T) {
+	t.Parallel()
+	repo := newSimpleTestRepo(t, &testConn{}, simpleTable)
+	cursor := EncodeCursor(Cursor{Values: map[string]any{"id": "a"}})
+	_, err := repo.Query(context.Background()).OrderBy("name", Asc).After(cursor).Page(nil)
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestQuery_Page_DerivesCursorFromMapping(t *testing.T) {
+	t.Parallel()
+	var users []memUser
+	for i := 1; i <= 5; i++ {
+		users = append(users, memUser{ID: i, Name: string(rune('a' + 5 - i)), Age: 20})
+	}
+	m := newMemUsers(t, users...)
+	ctx := context.Background()
+
+	first, err := m.Query(ctx).OrderBy("name", Asc).PageSize(2).Page()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cur, err := DecodeCursor(first.NextCursor)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cur.Values["name"] != "b" || cur.Values["id"] != int64(4) || len(cur.Values) != 2 {
+		t.Errorf("expected values of ordered columns only, got %v", cur.Values)
+	}
+	second, err := m.Query(ctx).OrderBy("name", Asc).PageSize(2).After(first.NextCursor).Page()
+	if err != nil || len(second.Items) != 2 || second.Items[0].Name != "c" {
+		t.Errorf("unexpected page: %+v, %v", second, err)
+	}
+
+	_, err = m.Query(ctx).OrderBy("created_at", Asc).PageSize(2).Page()
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("created_at is not part of the mapping, expected ErrInvalidCursor, got %v", err)
+	}
+	page, err := m.Query(ctx).OrderBy("created_at", Asc).PageSize(2).
+		Page(func(u memUser) map[string]any { return map[string]any{"created_at": nil, "id": u.ID} })
+	if err != nil || page.NextCursor == "" {
+		t.Errorf("explicit extractor must still be used, got %+v, %v", page, err)
+	}
+}
+
+func TestRepository_Page_DerivesCursorFromMapping(t *testing.T) {
+	t.Parallel()
+	conn := &testConn{queries: []testQueryResult{
+		{columns: []string{"id"}, rows: [][]sqlDriver.Value{{"a"}, {"b"}}},
+	}}
+	repo := newSimpleTestRepo(t, conn, simpleTable)
+	page, err := repo.Query(context.Background()).PageSize(1).Page()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cur, _ := DecodeCursor(page.NextCursor)
+	if !reflect.DeepEqual(cur.Values, map[string]any{"id": "a"}) {
+		t.Errorf("got %v", cur.Values)
+	}
+}
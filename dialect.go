@@ -9,8 +9,141 @@ type Dialect interface {
 	BatchInsertSQL(table string, columns []string, rowCount int) string
 }
 
+type OrderDialect interface {
+	OrderSQL(expr string, dir string, nulls string) string
+	DefaultNullsFirst(dir string) bool
+}
+
+type FoldDialect interface {
+	FoldSQL(op string, column string, placeholders []string) string
+	LikeEscape() string
+}
+
+type DateTruncDialect interface {
+	DateTrunc(unit string, expr string) string
+}
+
+type ArrayDialect interface {
+	ArraySQL(op string, column string, values []any, bind func(any) string) string
+}
+
+type JSONDialect interface {
+	JSONPathEqSQL(column string, path string, value any, bind func(any) string) string
+	JSONHasPathSQL(column string, path string, bind func(any) string) string
+}
+
+type TextSearchDialect interface {
+	TextSearchSQL(table string, columns []string, query string, bind func(any) string) string
+	TextRankSQL(table string, columns []string, query string, bind func(any) string) string
+}
+
+type StringDialect interface {
+	BackslashEscapes() bool
+}
+
 type UpsertOptions struct {
 	VersionColumn string
 	CreatedAt     string
 	UpdatedAt     string
 }
+
+func (o UpsertOptions) quote(d Dialect) UpsertOptions {
+	q := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		return quoteIdent(d, name)
+	}
+	return UpsertOptions{
+		VersionColumn: q(o.VersionColumn),
+		CreatedAt:     q(o.CreatedAt),
+		UpdatedAt:     q(o.UpdatedAt),
+	}
+}
+
+func orderSQL(d Dialect, expr string, dir string, nulls string) string {
+	if od, ok := d.(OrderDialect); ok {
+		return od.OrderSQL(expr, dir, nulls)
+	}
+	return emulatedOrderSQL(d, expr, dir, nulls)
+}
+
+func defaultNullsFirst(d Dialect, dir string) bool {
+	if od, ok := d.(OrderDialect); ok {
+		return od.DefaultNullsFirst(dir)
+	}
+	return dir == string(Asc)
+}
+
+func foldSQL(d Dialect, op string, column string, placeholders []string) string {
+	if fd, ok := d.(FoldDialect); ok {
+		return fd.FoldSQL(op, column, placeholders)
+	}
+	return lowerFoldSQL(op, column, placeholders)
+}
+
+func likeEscape(d Dialect) string {
+	if fd, ok := d.(FoldDialect); ok {
+		return fd.LikeEscape()
+	}
+	return ` ESCAPE '\'`
+}
+
+func backslashEscapes(d Dialect) bool {
+	if sd, ok := d.(StringDialect); ok {
+		return sd.BackslashEscapes()
+	}
+	return false
+}
+
+func dateTruncSQL(d Dialect, unit string, expr string) string {
+	if dd, ok := d.(DateTruncDialect); ok {
+		return dd.DateTrunc(unit, expr)
+	}
+	return ""
+}
+
+func arraySQL(d Dialect, op string, column string, values []any, bind func(any) string) string {
+	if ad, ok := d.(ArrayDialect); ok {
+		return ad.ArraySQL(op, column, values, bind)
+	}
+	return ""
+}
+
+func jsonPathEqSQL(d Dialect, column string, path string, value any, bind func(any) string) string {
+	if jd, ok := d.(JSONDialect); ok {
+		return jd.JSONPathEqSQL(column, path, value, bind)
+	}
+	return ""
+}
+
+func jsonHasPathSQL(d Dialect, column string, path string, bind func(any) string) string {
+	if jd, ok := d.(JSONDialect); ok {
+		return jd.JSONHasPathSQL(column, path, bind)
+	}
+	return ""
+}
+
+func textSearchSQL(d Dialect, table string, columns []string, query string, bind func(any) string) string {
+	if td, ok := d.(TextSearchDialect); ok {
+		return td.TextSearchSQL(table, columns, query, bind)
+	}
+	return ""
+}
+
+func textRankSQL(d Dialect, table string, columns []string, query string, bind func(any) string) string {
+	if td, ok := d.(TextSearchDialect); ok {
+		return td.TextRankSQL(table, columns, query, bind)
+	}
+	return ""
+}
+
+func emulatedOrderSQL(d Dialect, expr string, dir string, nulls string) string {
+	if nulls == "" || (nulls == string(NullsFirst)) == defaultNullsFirst(d, dir) {
+		return expr + " " + dir
+	}
+	if nulls == string(NullsFirst) {
+		return expr + " IS NULL DESC, " + expr + " " + dir
+	}
+	return expr + " IS NULL, " + expr + " " + dir
+}
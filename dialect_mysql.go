@@ -9,12 +9,57 @@ type mysqlDialect struct{}
 
 func MySQL() Dialect { return &mysqlDialect{} }
 
-func (d *mysqlDialect) Placeholder(_ int) string      { return "?" }
-func (d *mysqlDialect) Now() string                   { return "NOW()" }
-func (d *mysqlDialect) ILikeOp() string               { return "LIKE" }
-func (d *mysqlDialect) QuoteIdent(name string) string { return "`" + name + "`" }
+func (d *mysqlDialect) Placeholder(_ int) string { return "?" }
+func (d *mysqlDialect) Now() string              { return "NOW()" }
+func (d *mysqlDialect) ILikeOp() string          { return "LIKE" }
+func (d *mysqlDialect) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (d *mysqlDialect) FoldSQL(op string, column string, placeholders []string) string {
+	return lowerFoldSQL(op, column, placeholders)
+}
+
+func (d *mysqlDialect) OrderSQL(expr string, dir string, nulls string) string {
+	return emulatedOrderSQL(d, expr, dir, nulls)
+}
+
+func (d *mysqlDialect) DefaultNullsFirst(dir string) bool { return dir == string(Asc) }
+
+func (d *mysqlDialect) LikeEscape() string { return "" }
+
+func (d *mysqlDialect) BackslashEscapes() bool { return true }
+
+func (d *mysqlDialect) DateTrunc(unit string, expr string) string {
+	return fmt.Sprintf("CAST(DATE_FORMAT(%s, '%s') AS DATETIME)", expr, dateTruncFormats[DateUnit(unit)][0])
+}
+
+func (d *mysqlDialect) ArraySQL(op string, column string, values []any, bind func(any) string) string {
+	if op == arrayOverlaps {
+		return fmt.Sprintf("JSON_OVERLAPS(%s, JSON_ARRAY(%s))", column, bindAll(values, bind))
+	}
+	return fmt.Sprintf("JSON_CONTAINS(%s, JSON_ARRAY(%s))", column, bindAll(values, bind))
+}
+
+func (d *mysqlDialect) JSONPathEqSQL(column string, path string, value any, bind func(any) string) string {
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, %s)) = %s", column, bind(path), bind(value))
+}
+
+func (d *mysqlDialect) JSONHasPathSQL(column string, path string, bind func(any) string) string {
+	return fmt.Sprintf("JSON_CONTAINS_PATH(%s, 'one', %s)", column, bind(path))
+}
+
+func (d *mysqlDialect) TextSearchSQL(_ string, columns []string, query string, bind func(any) string) string {
+	return d.TextRankSQL("", columns, query, bind)
+}
+
+func (d *mysqlDialect) TextRankSQL(_ string, columns []string, query string, bind func(any) string) string {
+	return fmt.Sprintf("MATCH (%s) AGAINST (%s IN NATURAL LANGUAGE MODE)", strings.Join(quoteIdents(d, columns), ", "), bind(query))
+}
 
 func (d *mysqlDialect) UpsertSQL(table string, pks []string, columns []string, opts UpsertOptions) string {
+	table, pks, columns = quoteIdent(d, table), quoteIdents(d, pks), quoteIdents(d, columns)
+	opts = opts.quote(d)
 	pkSet := makeSet(pks)
 
 	insertCols := make([]string, 0, len(columns)+2)
@@ -66,6 +111,7 @@ func (d *mysqlDialect) UpsertSQL(table string, pks []string, columns []string, o
 }
 
 func (d *mysqlDialect) BatchInsertSQL(table string, columns []string, rowCount int) string {
+	table, columns = quoteIdent(d, table), quoteIdents(d, columns)
 	colCount := len(columns)
 	singleRow := make([]string, colCount)
 	for i := range singleRow {
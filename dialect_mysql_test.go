@@ -41,13 +41,13 @@ func TestMysqlDialect_UpsertSQL_Basic(t *testing.T) {
 	t.Parallel()
 	d := MySQL()
 	sql := d.UpsertSQL("users", []string{"id"}, []string{"id", "name"}, UpsertOptions{})
-	if !strings.Contains(sql, "INSERT INTO users") {
+	if !strings.Contains(sql, "INSERT INTO `users`") {
 		t.Errorf("expected INSERT INTO, got %q", sql)
 	}
 	if !strings.Contains(sql, "ON DUPLICATE KEY UPDATE") {
 		t.Errorf("expected ON DUPLICATE KEY UPDATE, got %q", sql)
 	}
-	if strings.Contains(sql, "id = VALUES(id)") {
+	if strings.Contains(sql, "`id` = VALUES(`id`)") {
 		t.Errorf("pk should be skipped in update, got %q", sql)
 	}
 }
@@ -67,7 +67,7 @@ func TestMysqlDialect_UpsertSQL_WithOptions(t *testing.T) {
 	if !strings.Contains(sql, "updated_at") {
 		t.Error("expected updated_at in SQL")
 	}
-	if !strings.Contains(sql, "version = version + 1") {
+	if !strings.Contains(sql, "`version` = `version` + 1") {
 		t.Errorf("expected version increment, got %q", sql)
 	}
 }
@@ -76,7 +76,7 @@ func TestMysqlDialect_BatchInsertSQL(t *testing.T) {
 	t.Parallel()
 	d := MySQL()
 	sql := d.BatchInsertSQL("items", []string{"a", "b"}, 3)
-	if !strings.Contains(sql, "INSERT INTO items") {
+	if !strings.Contains(sql, "INSERT INTO `items`") {
 		t.Errorf("expected INSERT INTO, got %q", sql)
 	}
 	if strings.Count(sql, "(?, ?)") != 3 {
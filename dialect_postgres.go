@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -9,12 +10,74 @@ type postgresDialect struct{}
 
 func Postgres() Dialect { return &postgresDialect{} }
 
-func (d *postgresDialect) Placeholder(n int) string      { return fmt.Sprintf("$%d", n) }
-func (d *postgresDialect) Now() string                   { return "NOW()" }
-func (d *postgresDialect) ILikeOp() string               { return "ILIKE" }
-func (d *postgresDialect) QuoteIdent(name string) string { return `"` + name + `"` }
+func (d *postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+func (d *postgresDialect) Now() string              { return "NOW()" }
+func (d *postgresDialect) ILikeOp() string          { return "ILIKE" }
+func (d *postgresDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d *postgresDialect) FoldSQL(op string, column string, placeholders []string) string {
+	if op == foldLike {
+		return column + " ILIKE " + placeholders[0]
+	}
+	return lowerFoldSQL(op, column, placeholders)
+}
+
+func (d *postgresDialect) OrderSQL(expr string, dir string, nulls string) string {
+	if nulls == "" {
+		return expr + " " + dir
+	}
+	return expr + " " + dir + " NULLS " + nulls
+}
+
+func (d *postgresDialect) DefaultNullsFirst(dir string) bool { return dir == string(Desc) }
+
+func (d *postgresDialect) LikeEscape() string { return "" }
+
+func (d *postgresDialect) DateTrunc(unit string, expr string) string {
+	return fmt.Sprintf("date_trunc('%s', %s)", unit, expr)
+}
+
+func (d *postgresDialect) ArraySQL(op string, column string, values []any, bind func(any) string) string {
+	if op == arrayAny {
+		return fmt.Sprintf("%s = ANY(%s)", bind(values[0]), column)
+	}
+	array := "ARRAY[" + bindAll(values, bind) + "]"
+	if op == arrayOverlaps {
+		return column + " && " + array
+	}
+	return column + " @> " + array
+}
+
+func (d *postgresDialect) JSONPathEqSQL(column string, path string, value any, bind func(any) string) string {
+	data, _ := json.Marshal(value)
+	return fmt.Sprintf("jsonb_extract_path(%s, %s) = %s::jsonb", column, bindJSONPath(path, bind), bind(string(data)))
+}
+
+func (d *postgresDialect) JSONHasPathSQL(column string, path string, bind func(any) string) string {
+	return fmt.Sprintf("jsonb_extract_path(%s, %s) IS NOT NULL", column, bindJSONPath(path, bind))
+}
+
+func (d *postgresDialect) TextSearchSQL(_ string, columns []string, query string, bind func(any) string) string {
+	return fmt.Sprintf("%s @@ plainto_tsquery(%s)", tsvector(quoteIdents(d, columns)), bind(query))
+}
+
+func (d *postgresDialect) TextRankSQL(_ string, columns []string, query string, bind func(any) string) string {
+	return fmt.Sprintf("ts_rank(%s, plainto_tsquery(%s))", tsvector(quoteIdents(d, columns)), bind(query))
+}
+
+func tsvector(columns []string) string {
+	parts := make([]string, len(columns))
+	for i, c := range columns {
+		parts[i] = "coalesce(" + c + ", '')"
+	}
+	return "to_tsvector(" + strings.Join(parts, " || ' ' || ") + ")"
+}
 
 func (d *postgresDialect) UpsertSQL(table string, pks []string, columns []string, opts UpsertOptions) string {
+	table, pks, columns = quoteIdent(d, table), quoteIdents(d, pks), quoteIdents(d, columns)
+	opts = opts.quote(d)
 	pkSet := makeSet(pks)
 
 	insertCols := make([]string, 0, len(columns)+2)
@@ -76,6 +139,7 @@ func (d *postgresDialect) UpsertSQL(table string, pks []string, columns []string
 }
 
 func (d *postgresDialect) BatchInsertSQL(table string, columns []string, rowCount int) string {
+	table, columns = quoteIdent(d, table), quoteIdents(d, columns)
 	colCount := len(columns)
 	rowPh := make([]string, rowCount)
 	for i := 0; i < rowCount; i++ {
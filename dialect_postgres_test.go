@@ -48,10 +48,10 @@ func TestPostgresDialect_UpsertSQL_Basic(t *testing.T) {
 	t.Parallel()
 	d := Postgres()
 	sql := d.UpsertSQL("users", []string{"id"}, []string{"id", "name"}, UpsertOptions{})
-	if !strings.Contains(sql, "ON CONFLICT (id) DO UPDATE SET") {
+	if !strings.Contains(sql, `ON CONFLICT ("id") DO UPDATE SET`) {
 		t.Errorf("expected ON CONFLICT, got %q", sql)
 	}
-	if !strings.Contains(sql, "name = EXCLUDED.name") {
+	if !strings.Contains(sql, `"name" = EXCLUDED."name"`) {
 		t.Errorf("expected EXCLUDED reference, got %q", sql)
 	}
 }
@@ -65,10 +65,10 @@ func TestPostgresDialect_UpsertSQL_WithVersion(t *testing.T) {
 		UpdatedAt:     "updated_at",
 	}
 	sql := d.UpsertSQL("users", []string{"id"}, []string{"id", "name", "version"}, opts)
-	if !strings.Contains(sql, "version = users.version + 1") {
+	if !strings.Contains(sql, `"version" = "users"."version" + 1`) {
 		t.Errorf("expected version increment, got %q", sql)
 	}
-	if !strings.Contains(sql, "WHERE users.version = EXCLUDED.version") {
+	if !strings.Contains(sql, `WHERE "users"."version" = EXCLUDED."version"`) {
 		t.Errorf("expected version WHERE clause, got %q", sql)
 	}
 }
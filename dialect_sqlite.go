@@ -9,12 +9,60 @@ type sqliteDialect struct{}
 
 func SQLite() Dialect { return &sqliteDialect{} }
 
-func (d *sqliteDialect) Placeholder(_ int) string      { return "?" }
-func (d *sqliteDialect) Now() string                   { return "datetime('now')" }
-func (d *sqliteDialect) ILikeOp() string               { return "LIKE" }
-func (d *sqliteDialect) QuoteIdent(name string) string { return `"` + name + `"` }
+func (d *sqliteDialect) Placeholder(_ int) string { return "?" }
+func (d *sqliteDialect) Now() string              { return "datetime('now')" }
+func (d *sqliteDialect) ILikeOp() string          { return "LIKE" }
+func (d *sqliteDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d *sqliteDialect) FoldSQL(op string, column string, placeholders []string) string {
+	switch op {
+	case foldLike:
+		return column + " LIKE " + placeholders[0]
+	case foldIn:
+		return column + " COLLATE NOCASE IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	return column + " COLLATE NOCASE " + op + " " + placeholders[0]
+}
+
+func (d *sqliteDialect) OrderSQL(expr string, dir string, nulls string) string {
+	return emulatedOrderSQL(d, expr, dir, nulls)
+}
+
+func (d *sqliteDialect) DefaultNullsFirst(dir string) bool { return dir == string(Asc) }
+
+func (d *sqliteDialect) LikeEscape() string { return ` ESCAPE '\'` }
+
+func (d *sqliteDialect) DateTrunc(unit string, expr string) string {
+	return fmt.Sprintf("strftime('%s', %s)", dateTruncFormats[DateUnit(unit)][1], expr)
+}
+
+func (d *sqliteDialect) JSONPathEqSQL(column string, path string, value any, bind func(any) string) string {
+	return fmt.Sprintf("json_extract(%s, %s) = %s", column, bind(path), bind(value))
+}
+
+func (d *sqliteDialect) JSONHasPathSQL(column string, path string, bind func(any) string) string {
+	return fmt.Sprintf("json_type(%s, %s) IS NOT NULL", column, bind(path))
+}
+
+func (d *sqliteDialect) TextSearchSQL(table string, columns []string, query string, bind func(any) string) string {
+	if table == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s MATCH %s", table, bind(fts5Query(columns, query)))
+}
+
+func (d *sqliteDialect) TextRankSQL(table string, _ []string, _ string, _ func(any) string) string {
+	if table == "" {
+		return ""
+	}
+	return "-" + table + ".rank"
+}
 
 func (d *sqliteDialect) UpsertSQL(table string, pks []string, columns []string, opts UpsertOptions) string {
+	table, pks, columns = quoteIdent(d, table), quoteIdents(d, pks), quoteIdents(d, columns)
+	opts = opts.quote(d)
 	pkSet := makeSet(pks)
 
 	insertCols := make([]string, 0, len(columns)+2)
@@ -76,6 +124,7 @@ func (d *sqliteDialect) UpsertSQL(table string, pks []string, columns []string,
 }
 
 func (d *sqliteDialect) BatchInsertSQL(table string, columns []string, rowCount int) string {
+	table, columns = quoteIdent(d, table), quoteIdents(d, columns)
 	colCount := len(columns)
 	singleRow := make([]string, colCount)
 	for i := range singleRow {
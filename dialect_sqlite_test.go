@@ -37,10 +37,10 @@ func TestSqliteDialect_UpsertSQL_Basic(t *testing.T) {
 	t.Parallel()
 	d := SQLite()
 	sql := d.UpsertSQL("users", []string{"id"}, []string{"id", "name"}, UpsertOptions{})
-	if !strings.Contains(sql, "ON CONFLICT(id) DO UPDATE SET") {
+	if !strings.Contains(sql, `ON CONFLICT("id") DO UPDATE SET`) {
 		t.Errorf("expected ON CONFLICT, got %q", sql)
 	}
-	if !strings.Contains(sql, "name = excluded.name") {
+	if !strings.Contains(sql, `"name" = excluded."name"`) {
 		t.Errorf("expected excluded ref, got %q", sql)
 	}
 }
@@ -54,10 +54,10 @@ func TestSqliteDialect_UpsertSQL_WithOptions(t *testing.T) {
 		UpdatedAt:     "updated_at",
 	}
 	sql := d.UpsertSQL("t", []string{"id"}, []string{"id", "name", "version"}, opts)
-	if !strings.Contains(sql, "version = version + 1") {
+	if !strings.Contains(sql, `"version" = "version" + 1`) {
 		t.Errorf("expected version inc, got %q", sql)
 	}
-	if !strings.Contains(sql, "WHERE version = excluded.version") {
+	if !strings.Contains(sql, `WHERE "version" = excluded."version"`) {
 		t.Errorf("expected WHERE clause, got %q", sql)
 	}
 }
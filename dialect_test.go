@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type baseDialect struct{ Dialect }
+
+func TestDialect_OptionalCapabilities(t *testing.T) {
+	t.Parallel()
+	d := baseDialect{Dialect: Postgres()}
+	tests := []struct {
+		name string
+		spec Spec
+		want string
+	}{
+		{"fold", EqFold("name", "a"), `LOWER("name") = LOWER($1)`},
+		{"ilike", ILike("name", "a%"), `LOWER("name") LIKE LOWER($1) ESCAPE '\'`},
+		{"like", Like("name", "a%"), `"name" LIKE $1 ESCAPE '\'`},
+		{"array", ArrayContains("tags", "a"), "FALSE"},
+		{"json", JSONPathEq("data", "$.a", 1), "FALSE"},
+		{"date trunc", EqExpr(DateTrunc(UnitDay, Col("at")), 1), "<invalid DateTrunc> = $1"},
+	}
+	for _, tt := range tests {
+		if sql, _, _ := tt.spec.ToSQL(d, 1); sql != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, sql, tt.want)
+		}
+	}
+	if got := buildOrderSQL(d, []orderClause{{column: "a", dir: Asc, nulls: NullsFirst}}); got != ` ORDER BY "a" ASC` {
+		t.Errorf("got %s", got)
+	}
+	if got := buildOrderSQL(d, []orderClause{{column: "a", dir: Asc, nulls: NullsLast}}); got != ` ORDER BY "a" IS NULL, "a" ASC` {
+		t.Errorf("got %s", got)
+	}
+
+	repo := New(newTestDB(t, &testConn{}), d, Simple(SimpleConfig[string]{Table: simpleTable}))
+	if _, err := repo.FindBy(context.Background(), ArrayContains("tags", "a")); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 type driver[T any] interface {
 	findOne(ctx context.Context, exec Executor, query string, args []any) (T, error)
 	findMany(ctx context.Context, exec Executor, query string, args []any) ([]T, error)
-	save(ctx context.Context, db TxBeginner, exec Executor, aggregate T) error
-	delete(ctx context.Context, db TxBeginner, exec Executor, ids []any) error
+	findManyTotal(ctx context.Context, exec Executor, query string, args []any) ([]T, int64, error)
+	save(ctx context.Context, tx txFunc, exec Executor, aggregate T) error
+	delete(ctx context.Context, tx txFunc, exec Executor, ids []any) error
 }
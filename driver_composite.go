@@ -40,14 +40,31 @@ func (d *compositeDriver[T, S]) findOne(ctx context.Context, exec Executor, quer
 
 //nolint:unused
 func (d *compositeDriver[T, S]) findMany(ctx context.Context, exec Executor, query string, args []any) ([]T, error) {
+	return d.scanMany(ctx, exec, query, args, nil)
+}
+
+//nolint:unused
+func (d *compositeDriver[T, S]) findManyTotal(
+	ctx context.Context, exec Executor, query string, args []any,
+) ([]T, int64, error) {
+	var total int64
+	items, err := d.scanMany(ctx, exec, query, args, &total)
+	return items, total, err
+}
+
+//nolint:unused
+func (d *compositeDriver[T, S]) scanMany(
+	ctx context.Context, exec Executor, query string, args []any, total *int64,
+) ([]T, error) {
 	rows, err := exec.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 
+	sc := withTotal(rows, total)
 	if len(d.relations) == 0 {
-		return d.scanAndBuildAll(rows)
+		return d.scanAndBuildAll(rows, sc)
 	}
 
 	type entry struct {
@@ -59,7 +76,7 @@ func (d *compositeDriver[T, S]) findMany(ctx context.Context, exec Executor, que
 	snapByID := make(map[string]S)
 
 	for rows.Next() {
-		snap, err := d.scanRoot(rows)
+		snap, err := d.scanRoot(sc)
 		if err != nil {
 			return nil, err
 		}
@@ -97,10 +114,10 @@ func (d *compositeDriver[T, S]) findMany(ctx context.Context, exec Executor, que
 }
 
 //nolint:unused
-func (d *compositeDriver[T, S]) scanAndBuildAll(rows *sql.Rows) ([]T, error) {
+func (d *compositeDriver[T, S]) scanAndBuildAll(rows *sql.Rows, sc Scanner) ([]T, error) {
 	var result []T
 	for rows.Next() {
-		snap, err := d.scanRoot(rows)
+		snap, err := d.scanRoot(sc)
 		if err != nil {
 			return nil, err
 		}
@@ -115,7 +132,7 @@ func (d *compositeDriver[T, S]) scanAndBuildAll(rows *sql.Rows) ([]T, error) {
 
 //nolint:unused
 func (d *compositeDriver[T, S]) save(
-	ctx context.Context, db TxBeginner, exec Executor, aggregate T,
+	ctx context.Context, tx txFunc, exec Executor, aggregate T,
 ) error {
 	cv := d.decompose(aggregate)
 
@@ -128,9 +145,9 @@ func (d *compositeDriver[T, S]) save(
 		return d.checkVersion(result)
 	}
 
-	if db != nil {
-		return inTx(ctx, db, func(tx *sql.Tx) error {
-			return d.saveWithChildren(ctx, tx, cv)
+	if tx != nil {
+		return tx(ctx, func(exec Executor) error {
+			return d.saveWithChildren(ctx, exec, cv)
 		})
 	}
 
@@ -185,7 +202,7 @@ func (d *compositeDriver[T, S]) saveWithChildren(
 
 //nolint:unused
 func (d *compositeDriver[T, S]) delete(
-	ctx context.Context, db TxBeginner, exec Executor, ids []any,
+	ctx context.Context, tx txFunc, exec Executor, ids []any,
 ) error {
 	if d.table.SoftDelete != "" || len(d.relations) == 0 {
 		query := d.table.deleteSQL(d.dialect)
@@ -193,9 +210,9 @@ func (d *compositeDriver[T, S]) delete(
 		return err
 	}
 
-	if db != nil {
-		return inTx(ctx, db, func(tx *sql.Tx) error {
-			return d.deleteWithChildren(ctx, tx, ids)
+	if tx != nil {
+		return tx(ctx, func(exec Executor) error {
+			return d.deleteWithChildren(ctx, exec, ids)
 		})
 	}
 
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	sqlDriver "database/sql/driver"
 	"fmt"
 	"testing"
@@ -142,7 +143,7 @@ func TestCompositeDriver_Save_NoRelations(t *testing.T) {
 	conn := &testConn{execs: []testExecResult{{rowsAffected: 1}}}
 	db := newTestDB(t, conn)
 	d := newCompositeDriver(nil, compositeTable, nil)
-	err := d.save(context.Background(), db, db, "o1")
+	err := d.save(context.Background(), dbTx(db), db, "o1")
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -153,7 +154,7 @@ func TestCompositeDriver_Save_NoRelations_ExecError(t *testing.T) {
 	conn := &testConn{execs: []testExecResult{{err: fmt.Errorf("exec fail")}}}
 	db := newTestDB(t, conn)
 	d := newCompositeDriver(nil, compositeTable, nil)
-	err := d.save(context.Background(), db, db, "o1")
+	err := d.save(context.Background(), dbTx(db), db, "o1")
 	if err == nil {
 		t.Error("expected error")
 	}
@@ -213,7 +214,7 @@ func TestCompositeDriver_Save_WithTx(t *testing.T) {
 		return CompositeValues{Root: []any{s, "name"}, Children: map[string][][]any{}}
 	}
 	d := newCompositeDriver([]Relation{itemsRelation}, compositeTable, decompose)
-	err := d.save(context.Background(), db, db, "o1")
+	err := d.save(context.Background(), dbTx(db), db, "o1")
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -226,7 +227,7 @@ func TestCompositeDriver_Delete_NoRelations(t *testing.T) {
 	d := newCompositeDriver(nil, compositeTable, nil)
 	var ids []any
 	ids = append(ids, "o1")
-	err := d.delete(context.Background(), db, db, ids)
+	err := d.delete(context.Background(), dbTx(db), db, ids)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -241,7 +242,7 @@ func TestCompositeDriver_Delete_SoftDelete(t *testing.T) {
 	d := newCompositeDriver([]Relation{itemsRelation}, tbl, nil)
 	var ids []any
 	ids = append(ids, "o1")
-	err := d.delete(context.Background(), db, db, ids)
+	err := d.delete(context.Background(), dbTx(db), db, ids)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -273,7 +274,7 @@ func TestCompositeDriver_Delete_WithRelations_WithTx(t *testing.T) {
 	d := newCompositeDriver([]Relation{itemsRelation}, compositeTable, nil)
 	var ids []any
 	ids = append(ids, "o1")
-	err := d.delete(context.Background(), db, db, ids)
+	err := d.delete(context.Background(), dbTx(db), db, ids)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -398,3 +399,9 @@ func TestCompositeDriver_SaveWithChildren_InsertChildError(t *testing.T) {
 		t.Error("expected error")
 	}
 }
+
+func dbTx(db TxBeginner) txFunc {
+	return func(ctx context.Context, fn func(Executor) error) error {
+		return inTx(ctx, db, func(tx *sql.Tx) error { return fn(tx) })
+	}
+}
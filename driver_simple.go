@@ -20,15 +20,32 @@ func (d *simpleDriver[T]) findOne(ctx context.Context, exec Executor, query stri
 
 //nolint:unused
 func (d *simpleDriver[T]) findMany(ctx context.Context, exec Executor, query string, args []any) ([]T, error) {
+	return d.scanMany(ctx, exec, query, args, nil)
+}
+
+//nolint:unused
+func (d *simpleDriver[T]) findManyTotal(
+	ctx context.Context, exec Executor, query string, args []any,
+) ([]T, int64, error) {
+	var total int64
+	items, err := d.scanMany(ctx, exec, query, args, &total)
+	return items, total, err
+}
+
+//nolint:unused
+func (d *simpleDriver[T]) scanMany(
+	ctx context.Context, exec Executor, query string, args []any, total *int64,
+) ([]T, error) {
 	rows, err := exec.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 
+	sc := withTotal(rows, total)
 	var result []T
 	for rows.Next() {
-		item, err := d.scan(rows)
+		item, err := d.scan(sc)
 		if err != nil {
 			return nil, err
 		}
@@ -38,7 +55,7 @@ func (d *simpleDriver[T]) findMany(ctx context.Context, exec Executor, query str
 }
 
 //nolint:unused
-func (d *simpleDriver[T]) save(ctx context.Context, _ TxBeginner, exec Executor, aggregate T) error {
+func (d *simpleDriver[T]) save(ctx context.Context, _ txFunc, exec Executor, aggregate T) error {
 	values := d.values(aggregate)
 	query := d.table.upsertSQL(d.dialect)
 	result, err := exec.ExecContext(ctx, query, values...)
@@ -49,7 +66,7 @@ func (d *simpleDriver[T]) save(ctx context.Context, _ TxBeginner, exec Executor,
 }
 
 //nolint:unused
-func (d *simpleDriver[T]) delete(ctx context.Context, _ TxBeginner, exec Executor, ids []any) error {
+func (d *simpleDriver[T]) delete(ctx context.Context, _ txFunc, exec Executor, ids []any) error {
 	query := d.table.deleteSQL(d.dialect)
 	_, err := exec.ExecContext(ctx, query, ids...)
 	return err
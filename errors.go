@@ -6,4 +6,12 @@ var (
 	ErrNotFound               = errors.New("entity not found")
 	ErrConcurrentModification = errors.New("concurrent modification")
 	ErrInvalidCursor          = errors.New("invalid cursor")
+	ErrUnknownColumn          = errors.New("unknown column")
+	ErrUnknownTable           = errors.New("unknown table")
+	ErrInvalidDirection       = errors.New("invalid sort direction")
+	ErrInvalidSort            = errors.New("invalid sort")
+	ErrInvalidSpec            = errors.New("invalid spec")
+	ErrUnsupportedSpec        = errors.New("unsupported spec")
+	ErrInvalidFilter          = errors.New("invalid filter")
+	ErrInvalidPage            = errors.New("invalid page")
 )
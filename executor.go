@@ -15,6 +15,8 @@ type TxBeginner interface {
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 }
 
+type txFunc func(ctx context.Context, fn func(Executor) error) error
+
 func inTx(ctx context.Context, db TxBeginner, fn func(*sql.Tx) error) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
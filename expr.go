@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Expression interface {
+	ExprSQL(d Dialect) string
+}
+
+type DateUnit string
+
+const (
+	UnitYear   DateUnit = "year"
+	UnitMonth  DateUnit = "month"
+	UnitDay    DateUnit = "day"
+	UnitHour   DateUnit = "hour"
+	UnitMinute DateUnit = "minute"
+	UnitSecond DateUnit = "second"
+)
+
+type colExpr struct{ name string }
+
+func Col(name string) Expression { return colExpr{name: name} }
+
+func (e colExpr) ExprSQL(d Dialect) string { return quoteIdent(d, e.name) }
+
+type rawExpr struct{ sql string }
+
+func Expr(sql string) Expression { return rawExpr{sql: sql} }
+
+func (e rawExpr) ExprSQL(Dialect) string { return e.sql }
+
+type funcExpr struct {
+	name string
+	args []Expression
+}
+
+func Lower(e Expression) Expression           { return funcExpr{name: "LOWER", args: []Expression{e}} }
+func Upper(e Expression) Expression           { return funcExpr{name: "UPPER", args: []Expression{e}} }
+func Coalesce(exprs ...Expression) Expression { return funcExpr{name: "COALESCE", args: exprs} }
+
+func (e funcExpr) ExprSQL(d Dialect) string {
+	args := make([]string, len(e.args))
+	for i, a := range e.args {
+		args[i] = a.ExprSQL(d)
+	}
+	return e.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (e funcExpr) validate() error {
+	if len(e.args) == 0 {
+		return fmt.Errorf("%w: %s without arguments", ErrInvalidSpec, e.name)
+	}
+	return validateExprs(e.args...)
+}
+
+type arithExpr struct {
+	left, right Expression
+	op          string
+}
+
+func Arith(left Expression, op string, right Expression) Expression {
+	return arithExpr{left: left, op: op, right: right}
+}
+
+func (e arithExpr) ExprSQL(d Dialect) string {
+	return "(" + e.left.ExprSQL(d) + " " + e.op + " " + e.right.ExprSQL(d) + ")"
+}
+
+func (e arithExpr) validate() error {
+	switch e.op {
+	case "+", "-", "*", "/", "%":
+		return validateExprs(e.left, e.right)
+	}
+	return fmt.Errorf("%w: arithmetic operator %q", ErrInvalidSpec, e.op)
+}
+
+type dateTruncExpr struct {
+	unit DateUnit
+	expr Expression
+}
+
+func DateTrunc(unit DateUnit, e Expression) Expression {
+	return dateTruncExpr{unit: unit, expr: e}
+}
+
+func (e dateTruncExpr) ExprSQL(d Dialect) string {
+	if sql := dateTruncSQL(d, string(e.unit), e.expr.ExprSQL(d)); sql != "" {
+		return sql
+	}
+	return invalidSQL("DateTrunc")
+}
+
+func (e dateTruncExpr) validate() error {
+	if _, ok := dateTruncFormats[e.unit]; !ok {
+		return fmt.Errorf("%w: date unit %q", ErrInvalidSpec, e.unit)
+	}
+	return validateExprs(e.expr)
+}
+
+var dateTruncFormats = map[DateUnit][2]string{
+	UnitYear:   {"%Y-01-01 00:00:00", "%Y-01-01 00:00:00"},
+	UnitMonth:  {"%Y-%m-01 00:00:00", "%Y-%m-01 00:00:00"},
+	UnitDay:    {"%Y-%m-%d 00:00:00", "%Y-%m-%d 00:00:00"},
+	UnitHour:   {"%Y-%m-%d %H:00:00", "%Y-%m-%d %H:00:00"},
+	UnitMinute: {"%Y-%m-%d %H:%i:00", "%Y-%m-%d %H:%M:00"},
+	UnitSecond: {"%Y-%m-%d %H:%i:%s", "%Y-%m-%d %H:%M:%S"},
+}
+
+func validateExprs(exprs ...Expression) error {
+	for _, e := range exprs {
+		if e == nil {
+			return fmt.Errorf("%w: nil expression", ErrInvalidSpec)
+		}
+		if v, ok := e.(interface{ validate() error }); ok {
+			if err := v.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func EqCol(left, right string) Spec    { return Eq(left, Col(right)) }
+func NotEqCol(left, right string) Spec { return NotEq(left, Col(right)) }
+func GtCol(left, right string) Spec    { return Gt(left, Col(right)) }
+func GteCol(left, right string) Spec   { return Gte(left, Col(right)) }
+func LtCol(left, right string) Spec    { return Lt(left, Col(right)) }
+func LteCol(left, right string) Spec   { return Lte(left, Col(right)) }
+
+func EqExpr(e Expression, value any) Spec    { return &comparisonSpec{left: e, op: "=", value: value} }
+func NotEqExpr(e Expression, value any) Spec { return &comparisonSpec{left: e, op: "!=", value: value} }
+func GtExpr(e Expression, value any) Spec    { return &comparisonSpec{left: e, op: ">", value: value} }
+func GteExpr(e Expression, value any) Spec   { return &comparisonSpec{left: e, op: ">=", value: value} }
+func LtExpr(e Expression, value any) Spec    { return &comparisonSpec{left: e, op: "<", value: value} }
+func LteExpr(e Expression, value any) Spec   { return &comparisonSpec{left: e, op: "<=", value: value} }
+
+func (s *comparisonSpec) validate() error {
+	if s.left != nil {
+		if err := validateExprs(s.left); err != nil {
+			return err
+		}
+	}
+	if e, ok := s.value.(Expression); ok {
+		return validateExprs(e)
+	}
+	return nil
+}
+
+func mapExprColumns(e Expression, fn func(string) (string, error)) (Expression, error) {
+	switch ex := e.(type) {
+	case colExpr:
+		name, err := fn(ex.name)
+		return colExpr{name: name}, err
+	case funcExpr:
+		args := make([]Expression, len(ex.args))
+		for i, a := range ex.args {
+			m, err := mapExprColumns(a, fn)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = m
+		}
+		return funcExpr{name: ex.name, args: args}, nil
+	case arithExpr:
+		left, err := mapExprColumns(ex.left, fn)
+		if err != nil {
+			return nil, err
+		}
+		right, err := mapExprColumns(ex.right, fn)
+		if err != nil {
+			return nil, err
+		}
+		return arithExpr{left: left, op: ex.op, right: right}, nil
+	case dateTruncExpr:
+		inner, err := mapExprColumns(ex.expr, fn)
+		if err != nil {
+			return nil, err
+		}
+		return dateTruncExpr{unit: ex.unit, expr: inner}, nil
+	}
+	return e, nil
+}
+
+func exprColumns(e Expression) []string {
+	switch ex := e.(type) {
+	case colExpr:
+		return []string{ex.name}
+	case funcExpr:
+		var cols []string
+		for _, a := range ex.args {
+			cols = append(cols, exprColumns(a)...)
+		}
+		return cols
+	case arithExpr:
+		return append(exprColumns(ex.left), exprColumns(ex.right)...)
+	case dateTruncExpr:
+		return exprColumns(ex.expr)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestColumnComparison_ToSQL(t *testing.T) {
+	t.Parallel()
+	spec := And(GtCol("shipped_at", "o.created_at"), Eq("status", "paid"))
+	sql, args, next := spec.ToSQL(Postgres(), 1)
+	want := `("shipped_at" > "o"."created_at") AND ("status" = $1)`
+	if sql != want || !reflect.DeepEqual(args, []any{"paid"}) || next != 2 {
+		t.Errorf("got %s %v %d", sql, args, next)
+	}
+	if got := Columns(spec); !reflect.DeepEqual(got, []string{"shipped_at", "o.created_at", "status"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestExpressionComparison_ToSQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		spec Spec
+		d    Dialect
+		want string
+	}{
+		{EqExpr(Lower(Col("email")), "a@b.c"), Postgres(), `LOWER("email") = $1`},
+		{EqExpr(Expr("lower(email)"), "a@b.c"), MySQL(), `lower(email) = ?`},
+		{GtExpr(Arith(Col("total"), "-", Col("discount")), 10), Postgres(), `("total" - "discount") > $1`},
+		{LteExpr(Coalesce(Col("shipped_at"), Col("created_at")), "x"), SQLite(), `COALESCE("shipped_at", "created_at") <= ?`},
+		{EqExpr(DateTrunc(UnitDay, Col("created_at")), "x"), Postgres(), `date_trunc('day', "created_at") = $1`},
+		{EqExpr(DateTrunc(UnitMonth, Col("created_at")), "x"), MySQL(),
+			"CAST(DATE_FORMAT(`created_at`, '%Y-%m-01 00:00:00') AS DATETIME) = ?"},
+		{EqExpr(DateTrunc(UnitMinute, Col("created_at")), "x"), SQLite(),
+			`strftime('%Y-%m-%d %H:%M:00', "created_at") = ?`},
+		{Eq("day", DateTrunc(UnitDay, Col("created_at"))), Postgres(), `"day" = date_trunc('day', "created_at")`},
+	}
+	for _, tt := range tests {
+		if sql, _, _ := tt.spec.ToSQL(tt.d, 1); sql != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, sql)
+		}
+	}
+}
+
+func TestExpression_Validate(t *testing.T) {
+	t.Parallel()
+	specs := []Spec{
+		EqExpr(Arith(Col("a"), "; DROP", Col("b")), 1),
+		EqExpr(DateTrunc("week", Col("a")), 1),
+		Eq("a", Coalesce()),
+	}
+	for _, s := range specs {
+		if err := Validate(s); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("expected ErrInvalidSpec, got %v", err)
+		}
+	}
+	if err := Validate(EqExpr(Upper(Col("a")), "X")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExpression_MapColumns(t *testing.T) {
+	t.Parallel()
+	spec := And(LtCol("a", "b"), GtExpr(Arith(DateTrunc(UnitDay, Col("c")), "+", Expr("1")), 0))
+	mapped, err := MapColumns(spec, func(c string) (string, error) { return "t." + c, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, _, _ := mapped.ToSQL(Postgres(), 1)
+	want := `("t"."a" < "t"."b") AND ((date_trunc('day', "t"."c") + 1) > $1)`
+	if sql != want {
+		t.Errorf("got %s", sql)
+	}
+	r := (&Repository[string]{table: whitelistTable, dialect: Postgres()}).WithColumnWhitelist(ColumnWhitelist{})
+	if _, err := r.prepareSpec(EqCol("name", "password")); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestExpression_Match(t *testing.T) {
+	t.Parallel()
+	row := MapRow{"a": 1, "b": 2}
+	if ok, err := Match(LtCol("a", "b"), row); err != nil || !ok {
+		t.Errorf("expected match, got %v, %v", ok, err)
+	}
+	if ok, err := Match(EqExpr(Col("b"), 2), row); err != nil || !ok {
+		t.Errorf("expected match, got %v, %v", ok, err)
+	}
+	if _, err := Match(EqExpr(Lower(Col("a")), "x"), row); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+	if _, err := MarshalSpec(EqCol("a", "b"), SpecJSONOptions{}); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+}
+
+func TestQuery_OrderByExpr(t *testing.T) {
+	t.Parallel()
+	r := (&Repository[string]{table: whitelistTable, dialect: Postgres()}).WithColumnWhitelist(ColumnWhitelist{})
+	q := r.Query(context.Background()).
+		OrderByExpr(Coalesce(Col("name"), Expr("''")), Desc).
+		OrderByExpr(Col("id"), Asc)
+	sql, _, err := buildQuerySQL(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `SELECT "id", "name" FROM "users" ORDER BY COALESCE("name", '') DESC, "id" ASC`
+	if sql != want {
+		t.Errorf("got %s", sql)
+	}
+	_, _, err = buildQuerySQL(r.Query(context.Background()).OrderByExpr(Lower(Col("password")), Asc))
+	if !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestMemory_ColumnComparisonAndOrder(t *testing.T) {
+	t.Parallel()
+	m := newMemUsers(t,
+		memUser{ID: 1, Name: "a", Age: 1, Version: 5},
+		memUser{ID: 2, Name: "b", Age: 9, Version: 1},
+	)
+	items, err := m.Query(context.Background()).Where(GtCol("age", "version")).All()
+	if err != nil || len(items) != 1 || items[0].ID != 2 {
+		t.Errorf("unexpected: %+v, %v", items, err)
+	}
+}
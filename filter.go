@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FieldType int
+
+const (
+	StringField FieldType = iota
+	IntField
+	FloatField
+	BoolField
+	TimeField
+)
+
+type FilterSchema map[string]FieldType
+
+type FilterError struct {
+	Pos int
+	Msg string
+	Err error
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid filter at position %d: %s", e.Pos, e.Msg)
+}
+
+func (e *FilterError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrInvalidFilter, e.Err}
+	}
+	return []error{ErrInvalidFilter}
+}
+
+func filterErrorf(pos int, format string, args ...any) *FilterError {
+	return &FilterError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func ParseFilter(s string, schema FilterSchema) (Spec, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var specs []Spec
+	for _, term := range splitEscaped(s, ',', 0) {
+		spec, err := parseFilterTerm(term, schema)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 1 {
+		return specs[0], nil
+	}
+	return And(specs...), nil
+}
+
+type filterToken struct {
+	text string
+	pos  int
+}
+
+func parseFilterTerm(term filterToken, schema FilterSchema) (Spec, error) {
+	parts := splitEscaped(term.text, ':', term.pos)
+	if len(parts) > 3 {
+		tail := parts[2]
+		tail.text = term.text[tail.pos-term.pos:]
+		parts = append(parts[:2], tail)
+	}
+	field := parts[0]
+	if len(parts) < 2 {
+		return nil, filterErrorf(field.pos, "expected field:operator[:value] in %q", term.text)
+	}
+	op := parts[1]
+
+	switch op.text {
+	case "null", "notnull":
+		if len(parts) != 2 {
+			return nil, filterErrorf(op.pos, "operator %q takes no value", op.text)
+		}
+		return buildFilterSpec(field, op, nil, schema)
+	}
+	if len(parts) != 3 {
+		return nil, filterErrorf(op.pos, "operator %q requires a value", op.text)
+	}
+
+	var values []filterToken
+	switch op.text {
+	case "in", "nin", "between":
+		values = splitEscaped(parts[2].text, '|', parts[2].pos)
+	default:
+		values = []filterToken{parts[2]}
+	}
+	for i := range values {
+		values[i].text = unescapeFilter(values[i].text)
+	}
+	return buildFilterSpec(field, op, values, schema)
+}
+
+func buildFilterSpec(field, op filterToken, values []filterToken, schema FilterSchema) (Spec, error) {
+	name := strings.TrimSpace(field.text)
+	typ, ok := schema[name]
+	if !ok {
+		return nil, &FilterError{Pos: field.pos, Msg: fmt.Sprintf("unknown field %q", name), Err: ErrUnknownColumn}
+	}
+
+	args := make([]any, len(values))
+	for i, v := range values {
+		val, err := coerceFilterValue(v.text, typ)
+		if err != nil {
+			return nil, filterErrorf(v.pos, "field %q: %v", name, err)
+		}
+		args[i] = val
+	}
+
+	switch op.text {
+	case "eq", "ne", "gt", "gte", "lt", "lte":
+		return &comparisonSpec{column: name, op: comparisonSQL[op.text], value: args[0]}, nil
+	case "in":
+		return In(name, args...), nil
+	case "nin":
+		return NotIn(name, args...), nil
+	case "between":
+		if len(args) != 2 {
+			return nil, filterErrorf(values[0].pos, "between expects two values separated by '|'")
+		}
+		return Between(name, args[0], args[1]), nil
+	case "like", "ilike":
+		if typ != StringField {
+			return nil, filterErrorf(op.pos, "operator %q requires a string field", op.text)
+		}
+		return &likeSpec{column: name, pattern: values[0].text, ilike: op.text == "ilike"}, nil
+	case "null":
+		return IsNull(name), nil
+	case "notnull":
+		return IsNotNull(name), nil
+	}
+	return nil, filterErrorf(op.pos, "unknown operator %q", op.text)
+}
+
+func coerceFilterValue(s string, typ FieldType) (any, error) {
+	switch typ {
+	case IntField:
+		return strconv.ParseInt(s, 10, 64)
+	case FloatField:
+		return strconv.ParseFloat(s, 64)
+	case BoolField:
+		return strconv.ParseBool(s)
+	case TimeField:
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse(time.DateOnly, s); err == nil {
+			return t, nil
+		}
+		return nil, errors.New("expected RFC 3339 timestamp or YYYY-MM-DD date")
+	}
+	return s, nil
+}
+
+func splitEscaped(s string, sep byte, base int) []filterToken {
+	var tokens []filterToken
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			tokens = append(tokens, filterToken{text: s[start:i], pos: base + start})
+			start = i + 1
+		}
+	}
+	return append(tokens, filterToken{text: s[start:], pos: base + start})
+}
+
+func unescapeFilter(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
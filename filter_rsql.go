@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+)
+
+var rsqlOperators = map[string]string{
+	"==": "eq", "!=": "ne",
+	"=lt=": "lt", "<": "lt", "=le=": "lte", "<=": "lte",
+	"=gt=": "gt", ">": "gt", "=ge=": "gte", ">=": "gte",
+	"=in=": "in", "=out=": "nin", "=between=": "between",
+	"=like=": "like", "=ilike=": "ilike", "=isnull=": "isnull",
+}
+
+type rsqlParser struct {
+	s      string
+	pos    int
+	schema FilterSchema
+}
+
+func ParseRSQL(s string, schema FilterSchema) (Spec, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	p := &rsqlParser{s: s, schema: schema}
+	spec, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.s) {
+		return nil, filterErrorf(p.pos, "unexpected %q", p.s[p.pos])
+	}
+	return spec, nil
+}
+
+func (p *rsqlParser) parseOr() (Spec, error) {
+	return p.parseList(',', p.parseAnd, Or)
+}
+
+func (p *rsqlParser) parseAnd() (Spec, error) {
+	return p.parseList(';', p.parseConstraint, And)
+}
+
+func (p *rsqlParser) parseList(sep byte, next func() (Spec, error), join func(...Spec) Spec) (Spec, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	specs := []Spec{first}
+	for p.consume(sep) {
+		s, err := next()
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, s)
+	}
+	if len(specs) == 1 {
+		return first, nil
+	}
+	return join(specs...), nil
+}
+
+func (p *rsqlParser) parseConstraint() (Spec, error) {
+	if !p.consume('(') {
+		return p.parseComparison()
+	}
+	spec, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.consume(')') {
+		return nil, filterErrorf(p.pos, "expected ')'")
+	}
+	return spec, nil
+}
+
+func (p *rsqlParser) parseComparison() (Spec, error) {
+	p.skipSpaces()
+	field := filterToken{pos: p.pos}
+	for p.pos < len(p.s) && isSelectorChar(p.s[p.pos]) {
+		p.pos++
+	}
+	field.text = p.s[field.pos:p.pos]
+	if field.text == "" {
+		return nil, filterErrorf(p.pos, "expected field name")
+	}
+
+	op, err := p.readOperator()
+	if err != nil {
+		return nil, err
+	}
+	values, err := p.readArguments()
+	if err != nil {
+		return nil, err
+	}
+
+	if op.text == "isnull" {
+		isNull, err := strconv.ParseBool(values[0].text)
+		if err != nil || len(values) != 1 {
+			return nil, filterErrorf(values[0].pos, "=isnull= expects true or false")
+		}
+		op.text = "notnull"
+		if isNull {
+			op.text = "null"
+		}
+		return buildFilterSpec(field, op, nil, p.schema)
+	}
+	if len(values) != 1 && op.text != "in" && op.text != "nin" && op.text != "between" {
+		return nil, filterErrorf(values[0].pos, "operator expects a single value")
+	}
+	return buildFilterSpec(field, op, values, p.schema)
+}
+
+func (p *rsqlParser) readOperator() (filterToken, error) {
+	start := p.pos
+	end := start
+	switch {
+	case strings.HasPrefix(p.s[start:], "=="), strings.HasPrefix(p.s[start:], "!="),
+		strings.HasPrefix(p.s[start:], "<="), strings.HasPrefix(p.s[start:], ">="):
+		end = start + 2
+	case strings.HasPrefix(p.s[start:], "<"), strings.HasPrefix(p.s[start:], ">"):
+		end = start + 1
+	case strings.HasPrefix(p.s[start:], "="):
+		if i := strings.IndexByte(p.s[start+1:], '='); i >= 0 {
+			end = start + i + 2
+		}
+	}
+	name, ok := rsqlOperators[p.s[start:end]]
+	if !ok {
+		return filterToken{}, filterErrorf(start, "expected comparison operator")
+	}
+	p.pos = end
+	return filterToken{text: name, pos: start}, nil
+}
+
+func (p *rsqlParser) readArguments() ([]filterToken, error) {
+	if !p.consume('(') {
+		v, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		return []filterToken{v}, nil
+	}
+	var values []filterToken
+	for {
+		v, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if p.consume(')') {
+			return values, nil
+		}
+		if !p.consume(',') {
+			return nil, filterErrorf(p.pos, "expected ',' or ')'")
+		}
+	}
+}
+
+func (p *rsqlParser) readValue() (filterToken, error) {
+	p.skipSpaces()
+	start := p.pos
+	if p.pos < len(p.s) && (p.s[p.pos] == '"' || p.s[p.pos] == '\'') {
+		return p.readQuoted()
+	}
+	for p.pos < len(p.s) && !strings.ContainsRune(`"'();, `, rune(p.s[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return filterToken{}, filterErrorf(start, "expected value")
+	}
+	return filterToken{text: p.s[start:p.pos], pos: start}, nil
+}
+
+func (p *rsqlParser) readQuoted() (filterToken, error) {
+	start := p.pos
+	quote := p.s[p.pos]
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s):
+			p.pos++
+			b.WriteByte(p.s[p.pos])
+		case c == quote:
+			p.pos++
+			return filterToken{text: b.String(), pos: start}, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return filterToken{}, filterErrorf(start, "unterminated string")
+}
+
+func (p *rsqlParser) consume(c byte) bool {
+	p.skipSpaces()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *rsqlParser) skipSpaces() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func isSelectorChar(c byte) bool {
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var filterSchema = FilterSchema{
+	"status":     StringField,
+	"name":       StringField,
+	"amount":     IntField,
+	"rate":       FloatField,
+	"active":     BoolField,
+	"created_at": TimeField,
+	"deleted_at": TimeField,
+}
+
+func filterSQL(t *testing.T, s Spec) (string, []any) {
+	t.Helper()
+	sql, args, _ := s.ToSQL(Postgres(), 1)
+	return sql, args
+}
+
+func TestParseFilter_Terms(t *testing.T) {
+	t.Parallel()
+	spec, err := ParseFilter("status:eq:active,amount:gte:100,name:ilike:%foo%", filterSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, args := filterSQL(t, spec)
+	if sql != `("status" = $1) AND ("amount" >= $2) AND ("name" ILIKE $3)` {
+		t.Errorf("got %q", sql)
+	}
+	if !reflect.DeepEqual(args, []any{"active", int64(100), "%foo%"}) {
+		t.Errorf("got %#v", args)
+	}
+}
+
+func TestParseFilter_ListsNullsAndTime(t *testing.T) {
+	t.Parallel()
+	spec, err := ParseFilter(
+		`status:in:new|paid,amount:between:1|5,deleted_at:null,created_at:lt:2024-05-01T10:00:00Z,name:eq:a\,b`,
+		filterSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, args := filterSQL(t, spec)
+	want := `("status" IN ($1, $2)) AND ("amount" BETWEEN $3 AND $4) AND ("deleted_at" IS NULL) AND ` +
+		`("created_at" < $5) AND ("name" = $6)`
+	if sql != want {
+		t.Errorf("got %q", sql)
+	}
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if !reflect.DeepEqual(args, []any{"new", "paid", int64(1), int64(5), ts, "a,b"}) {
+		t.Errorf("got %#v", args)
+	}
+}
+
+func TestParseFilter_Single(t *testing.T) {
+	t.Parallel()
+	spec, err := ParseFilter("active:eq:true", filterSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, args := filterSQL(t, spec); args[0] != true {
+		t.Errorf("expected bool coercion, got %#v", args[0])
+	}
+}
+
+func TestParseFilter_Empty(t *testing.T) {
+	t.Parallel()
+	spec, err := ParseFilter("", filterSchema)
+	if spec != nil || err != nil {
+		t.Errorf("expected nil, got %v, %v", spec, err)
+	}
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		pos int
+	}{
+		{"status", 0},
+		{"status:eq:a,password:eq:x", 12},
+		{"status:eq:a,amount:gte:abc", 23},
+		{"amount:like:1", 7},
+		{"status:nope:a", 7},
+		{"status:eq", 7},
+		{"deleted_at:null:x", 11},
+		{"amount:between:1", 15},
+	}
+	for _, tt := range tests {
+		_, err := ParseFilter(tt.in, filterSchema)
+		var fe *FilterError
+		if !errors.As(err, &fe) {
+			t.Errorf("%q: expected FilterError, got %v", tt.in, err)
+			continue
+		}
+		if !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("%q: expected ErrInvalidFilter", tt.in)
+		}
+		if fe.Pos != tt.pos {
+			t.Errorf("%q: expected position %d, got %d (%v)", tt.in, tt.pos, fe.Pos, err)
+		}
+	}
+}
+
+func TestParseFilter_UnknownFieldIsUnknownColumn(t *testing.T) {
+	t.Parallel()
+	_, err := ParseFilter("password:eq:x", filterSchema)
+	if !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestParseRSQL_Precedence(t *testing.T) {
+	t.Parallel()
+	spec, err := ParseRSQL(`status==active;(amount=gt=100,name=like="%a b%")`, filterSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, args := filterSQL(t, spec)
+	if sql != `("status" = $1) AND (("amount" > $2) OR ("name" LIKE $3))` {
+		t.Errorf("got %q", sql)
+	}
+	if !reflect.DeepEqual(args, []any{"active", int64(100), "%a b%"}) {
+		t.Errorf("got %#v", args)
+	}
+}
+
+func TestParseRSQL_Operators(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"amount!=1", `"amount" != $1`},
+		{"amount<1", `"amount" < $1`},
+		{"amount<=1", `"amount" <= $1`},
+		{"amount>=1", `"amount" >= $1`},
+		{"amount=le=1", `"amount" <= $1`},
+		{"status=in=(a,'b c')", `"status" IN ($1, $2)`},
+		{"status=out=(a)", `"status" NOT IN ($1)`},
+		{"amount=between=(1,2)", `"amount" BETWEEN $1 AND $2`},
+		{"name=ilike=%x%", `"name" ILIKE $1`},
+		{"deleted_at=isnull=true", `"deleted_at" IS NULL`},
+		{"deleted_at=isnull=false", `"deleted_at" IS NOT NULL`},
+		{"rate=gt=1.5", `"rate" > $1`},
+	}
+	for _, tt := range tests {
+		spec, err := ParseRSQL(tt.in, filterSchema)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if sql, _ := filterSQL(t, spec); sql != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.in, tt.want, sql)
+		}
+	}
+}
+
+func TestParseRSQL_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		pos int
+	}{
+		{"status", 6},
+		{"status=xx=a", 6},
+		{"(status==a", 10},
+		{"status==a)", 9},
+		{`status=="abc`, 8},
+		{"status==", 8},
+		{"amount==x", 8},
+		{"secret==x", 0},
+		{"status=in=(a;b)", 12},
+		{"status==(a,b)", 9},
+		{"deleted_at=isnull=maybe", 18},
+	}
+	for _, tt := range tests {
+		_, err := ParseRSQL(tt.in, filterSchema)
+		var fe *FilterError
+		if !errors.As(err, &fe) {
+			t.Errorf("%q: expected FilterError, got %v", tt.in, err)
+			continue
+		}
+		if fe.Pos != tt.pos {
+			t.Errorf("%q: expected position %d, got %d (%v)", tt.in, tt.pos, fe.Pos, err)
+		}
+	}
+}
+
+func TestParseFilter_ComposesWithWhere(t *testing.T) {
+	t.Parallel()
+	spec, err := ParseFilter("status:eq:active", filterSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := &Repository[string]{table: simpleTable, dialect: Postgres()}
+	q := r.Query(context.Background()).Where(Eq("id", "x")).Where(spec)
+	empty, _ := ParseFilter("", filterSchema)
+	q.Where(empty)
+	sql, _, err := buildQuerySQL(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql != `SELECT "id" FROM "items" WHERE ("id" = $1) AND ("status" = $2)` {
+		t.Errorf("got %q", sql)
+	}
+}
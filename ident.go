@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+func quoteIdent(d Dialect, name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = d.QuoteIdent(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func quoteIdents(d Dialect, names []string) []string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = quoteIdent(d, n)
+	}
+	return quoted
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isQualifiedIdent(s string) bool {
+	for _, p := range strings.Split(s, ".") {
+		if !isIdent(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkIdent(kind, name string) error {
+	if !isQualifiedIdent(name) {
+		return fmt.Errorf("%w: %s %q is not an identifier, use Expr or Raw for expressions", ErrInvalidSpec, kind, name)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import "testing"
+
+func TestQuoteIdent(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		d    Dialect
+		in   string
+		want string
+	}{
+		{"plain", Postgres(), "name", `"name"`},
+		{"reserved word", Postgres(), "order", `"order"`},
+		{"schema qualified", Postgres(), "sales.order", `"sales"."order"`},
+		{"mysql", MySQL(), "group", "`group`"},
+		{"sqlite", SQLite(), "main.user", `"main"."user"`},
+		{"expression", Postgres(), "lower(email)", `"lower(email)"`},
+		{"embedded quote", Postgres(), `"Name"`, `"""Name"""`},
+		{"leading digit", Postgres(), "1col", `"1col"`},
+		{"mysql injection", MySQL(), "a` OR 1=1 --", "`a`` OR 1=1 --`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := quoteIdent(tt.d, tt.in); got != tt.want {
+				t.Errorf("quoteIdent(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteIdent_EscapesQuoteCharacters(t *testing.T) {
+	t.Parallel()
+	if got := Postgres().QuoteIdent(`a"b`); got != `"a""b"` {
+		t.Errorf("got %q", got)
+	}
+	if got := MySQL().QuoteIdent("a`b"); got != "`a``b`" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTable_SelectFrom_ReservedNames(t *testing.T) {
+	t.Parallel()
+	tbl := Table{Name: "shop.order", Columns: []string{"id", "group", "user"}}
+	want := `SELECT "id", "group", "user" FROM "shop"."order"`
+	if got := tbl.selectFrom(Postgres()); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSpec_ExpressionsOnlyThroughExpr(t *testing.T) {
+	t.Parallel()
+	if sql, _, _ := Eq("lower(email)", "a@b.c").ToSQL(Postgres(), 1); sql != `"lower(email)" = $1` {
+		t.Errorf("got %q", sql)
+	}
+	if sql, _, _ := EqExpr(Expr("lower(email)"), "a@b.c").ToSQL(Postgres(), 1); sql != "lower(email) = $1" {
+		t.Errorf("got %q", sql)
+	}
+}
@@ -1217,7 +1217,7 @@ func TestWithLogger_QueryBuilder_UsesLogger(t *testing.T) {
 	repo := newSimpleTestRepoWithLogger(t, conn, simpleTable, lg)
 
 	q := repo.Query(context.Background())
-	if q.repo.logger != lg {
+	if q.src.(*Repository[string]).logger != lg {
 		t.Error("query should reference repo with logger")
 	}
 	_, _ = q.All()
@@ -7,6 +7,13 @@ type Mapping[T any] interface {
 type mappingResult[T any] struct {
 	driver driver[T]
 	table  Table
+	codec  rowCodec[T]
+}
+
+type rowCodec[T any] struct {
+	relations []Relation
+	encode    func(T) CompositeValues
+	decode    func(root []any, children map[string][][]any) (T, error)
 }
 
 type SimpleConfig[T any] struct {
@@ -33,6 +40,12 @@ func (m *simpleMapping[T]) configure(dialect Dialect) mappingResult[T] {
 			values:  m.cfg.Values,
 		},
 		table: m.cfg.Table,
+		codec: rowCodec[T]{
+			encode: func(t T) CompositeValues { return CompositeValues{Root: m.cfg.Values(t)} },
+			decode: func(root []any, _ map[string][][]any) (T, error) {
+				return m.cfg.Scan(&valuesScanner{values: root})
+			},
+		},
 	}
 }
 
@@ -69,5 +82,27 @@ func (m *compositeMapping[T, S]) configure(dialect Dialect) mappingResult[T] {
 			extractPK: m.cfg.ExtractPK,
 		},
 		table: m.cfg.Table,
+		codec: rowCodec[T]{
+			relations: m.cfg.Relations,
+			encode:    m.cfg.Decompose,
+			decode:    m.decode,
+		},
+	}
+}
+
+//nolint:unused
+func (m *compositeMapping[T, S]) decode(root []any, children map[string][][]any) (T, error) {
+	var zero T
+	snap, err := m.cfg.ScanRoot(&valuesScanner{values: root})
+	if err != nil {
+		return zero, err
+	}
+	for _, rel := range m.cfg.Relations {
+		for _, row := range children[rel.Table] {
+			if err := m.cfg.ScanChild(rel.Table, &valuesScanner{values: row}, snap); err != nil {
+				return zero, err
+			}
+		}
 	}
+	return m.cfg.Build(snap)
 }
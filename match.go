@@ -0,0 +1,429 @@
+package repository
+
+import (
+	sqlDriver "database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Row interface {
+	Value(column string) (any, bool)
+}
+
+type MapRow map[string]any
+
+func (r MapRow) Value(column string) (any, bool) {
+	v, ok := r[column]
+	return v, ok
+}
+
+type RowFunc func(column string) (any, bool)
+
+func (f RowFunc) Value(column string) (any, bool) { return f(column) }
+
+type Matcher interface {
+	Match(row Row) (bool, error)
+}
+
+func Match(s Spec, row Row) (bool, error) {
+	t, err := evalSpec(s, row)
+	return t == truthTrue, err
+}
+
+type truth int8
+
+const (
+	truthUnknown truth = iota
+	truthFalse
+	truthTrue
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+func (t truth) not() truth {
+	switch t {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	}
+	return truthUnknown
+}
+
+type evaluator interface {
+	eval(row Row) (truth, error)
+}
+
+func evalSpec(s Spec, row Row) (truth, error) {
+	switch sp := s.(type) {
+	case evaluator:
+		return sp.eval(row)
+	case Matcher:
+		ok, err := sp.Match(row)
+		return truthOf(ok), err
+	}
+	return truthUnknown, fmt.Errorf("%w: %T cannot be evaluated in memory", ErrUnsupportedSpec, s)
+}
+
+func rowValue(row Row, column string) (any, error) {
+	v, ok := row.Value(column)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, column)
+	}
+	return normalizeValue(v)
+}
+
+func (s *comparisonSpec) Match(row Row) (bool, error) { return Match(s, row) }
+func (s *inSpec) Match(row Row) (bool, error)         { return Match(s, row) }
+func (s *likeSpec) Match(row Row) (bool, error)       { return Match(s, row) }
+func (s *betweenSpec) Match(row Row) (bool, error)    { return Match(s, row) }
+func (s *nullSpec) Match(row Row) (bool, error)       { return Match(s, row) }
+func (s *andSpec) Match(row Row) (bool, error)        { return Match(s, row) }
+func (s *orSpec) Match(row Row) (bool, error)         { return Match(s, row) }
+func (s *notSpec) Match(row Row) (bool, error)        { return Match(s, row) }
+
+func (s *comparisonSpec) eval(row Row) (truth, error) {
+	var left Expression = colExpr{name: s.column}
+	if s.left != nil {
+		left = s.left
+	}
+	v, err := exprValue(row, left)
+	if err != nil {
+		return truthUnknown, err
+	}
+	value := s.value
+	if e, ok := value.(Expression); ok {
+		if value, err = exprValue(row, e); err != nil {
+			return truthUnknown, err
+		}
+	}
+	return compareOp(v, s.op, value)
+}
+
+func exprValue(row Row, e Expression) (any, error) {
+	c, ok := e.(colExpr)
+	if !ok {
+		return nil, fmt.Errorf("%w: expression %T", ErrUnsupportedSpec, e)
+	}
+	return rowValue(row, c.name)
+}
+
+func compareOp(a any, op string, b any) (truth, error) {
+	b, err := normalizeValue(b)
+	if err != nil || a == nil || b == nil {
+		return truthUnknown, err
+	}
+	if op == "=" || op == "!=" {
+		eq, err := valuesEqual(a, b)
+		if err != nil {
+			return truthUnknown, err
+		}
+		return truthOf(eq == (op == "=")), nil
+	}
+	c, err := compareValues(a, b)
+	if err != nil {
+		return truthUnknown, err
+	}
+	switch op {
+	case ">":
+		return truthOf(c > 0), nil
+	case ">=":
+		return truthOf(c >= 0), nil
+	case "<":
+		return truthOf(c < 0), nil
+	case "<=":
+		return truthOf(c <= 0), nil
+	}
+	return truthUnknown, fmt.Errorf("%w: operator %q", ErrUnsupportedSpec, op)
+}
+
+func (s *inSpec) eval(row Row) (truth, error) {
+	v, err := rowValue(row, s.column)
+	if err != nil {
+		return truthUnknown, err
+	}
+	result := truthFalse
+	for _, candidate := range s.values {
+		t, err := compareOp(v, "=", candidate)
+		if err != nil {
+			return truthUnknown, err
+		}
+		if t == truthTrue {
+			result = truthTrue
+			break
+		}
+		if t == truthUnknown {
+			result = truthUnknown
+		}
+	}
+	if s.negate {
+		return result.not(), nil
+	}
+	return result, nil
+}
+
+func (s *likeSpec) eval(row Row) (truth, error) {
+	v, err := rowValue(row, s.column)
+	if err != nil || v == nil {
+		return truthUnknown, err
+	}
+	str, ok := asString(v)
+	if !ok {
+		return truthUnknown, fmt.Errorf("%w: LIKE on %T", ErrUnsupportedSpec, v)
+	}
+	pattern := s.pattern
+	if s.ilike {
+		str, pattern = strings.ToLower(str), strings.ToLower(pattern)
+	}
+	return truthOf(likeMatch(str, pattern)), nil
+}
+
+func (s *betweenSpec) eval(row Row) (truth, error) {
+	v, err := rowValue(row, s.column)
+	if err != nil {
+		return truthUnknown, err
+	}
+	lower, err := compareOp(v, ">=", s.from)
+	if err != nil {
+		return truthUnknown, err
+	}
+	upper, err := compareOp(v, "<=", s.to)
+	if err != nil {
+		return truthUnknown, err
+	}
+	return andTruth(lower, upper), nil
+}
+
+func (s *nullSpec) eval(row Row) (truth, error) {
+	v, err := rowValue(row, s.column)
+	if err != nil {
+		return truthUnknown, err
+	}
+	return truthOf((v == nil) != s.not), nil
+}
+
+func (s *andSpec) eval(row Row) (truth, error) {
+	result := truthTrue
+	for _, spec := range s.specs {
+		t, err := evalSpec(spec, row)
+		if err != nil {
+			return truthUnknown, err
+		}
+		result = andTruth(result, t)
+	}
+	return result, nil
+}
+
+func (s *orSpec) eval(row Row) (truth, error) {
+	result := truthFalse
+	for _, spec := range s.specs {
+		t, err := evalSpec(spec, row)
+		if err != nil {
+			return truthUnknown, err
+		}
+		result = andTruth(result.not(), t.not()).not()
+	}
+	return result, nil
+}
+
+func (s *notSpec) eval(row Row) (truth, error) {
+	t, err := evalSpec(s.spec, row)
+	return t.not(), err
+}
+
+func andTruth(a, b truth) truth {
+	switch {
+	case a == truthFalse || b == truthFalse:
+		return truthFalse
+	case a == truthUnknown || b == truthUnknown:
+		return truthUnknown
+	}
+	return truthTrue
+}
+
+func normalizeValue(v any) (any, error) {
+	if valuer, ok := v.(sqlDriver.Valuer); ok {
+		return valuer.Value()
+	}
+	if v == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return normalizeValue(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Array:
+		if id, ok := v.([16]byte); ok {
+			return formatUUID(id), nil
+		}
+	}
+	return v, nil
+}
+
+func valuesEqual(a, b any) (bool, error) {
+	if c, err := compareValues(a, b); err == nil {
+		return c == 0, nil
+	}
+	return reflect.DeepEqual(a, b), nil
+}
+
+func compareValues(a, b any) (int, error) {
+	switch av := a.(type) {
+	case int64:
+		switch bv := b.(type) {
+		case int64:
+			return compareOrdered(av, bv), nil
+		case float64:
+			return compareOrdered(float64(av), bv), nil
+		}
+	case float64:
+		switch bv := b.(type) {
+		case int64:
+			return compareOrdered(av, float64(bv)), nil
+		case float64:
+			return compareOrdered(av, bv), nil
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			return compareOrdered(boolRank(av), boolRank(bv)), nil
+		}
+	case time.Time:
+		if bt, ok := asTime(b); ok {
+			return av.Compare(bt), nil
+		}
+	case string, []byte:
+		if at, ok := asTime(b); ok {
+			if t, ok := asTime(a); ok {
+				return t.Compare(at), nil
+			}
+		}
+		as, _ := asString(a)
+		if bs, ok := asString(b); ok {
+			return strings.Compare(as, bs), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: cannot compare %T with %T", ErrUnsupportedSpec, a, b)
+}
+
+func compareOrdered[V int64 | float64](a, b V) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolRank(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func asString(v any) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case []byte:
+		return string(s), true
+	}
+	return "", false
+}
+
+func asTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string, []byte:
+		s, _ := asString(v)
+		var d time.Time
+		if err := assignTime(&d, s); err == nil {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func likeMatch(s, pattern string) bool {
+	return likeRunes([]rune(s), []rune(pattern))
+}
+
+type likeToken struct {
+	r    rune
+	kind byte
+}
+
+const (
+	likeLiteral byte = iota
+	likeOne
+	likeMany
+)
+
+func likeTokens(p []rune) []likeToken {
+	tokens := make([]likeToken, 0, len(p))
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] == '%':
+			tokens = append(tokens, likeToken{kind: likeMany})
+		case p[i] == '_':
+			tokens = append(tokens, likeToken{kind: likeOne})
+		case p[i] == '\\' && i+1 < len(p):
+			i++
+			tokens = append(tokens, likeToken{r: p[i]})
+		default:
+			tokens = append(tokens, likeToken{r: p[i]})
+		}
+	}
+	return tokens
+}
+
+func likeRunes(s, pattern []rune) bool {
+	p := likeTokens(pattern)
+	si, pi := 0, 0
+	star, mark := -1, 0
+	for si < len(s) {
+		switch {
+		case pi < len(p) && p[pi].kind == likeMany:
+			star, mark = pi, si
+			pi++
+		case pi < len(p) && (p[pi].kind == likeOne || p[pi].r == s[si]):
+			si++
+			pi++
+		case star >= 0:
+			mark++
+			si, pi = mark, star+1
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi].kind == likeMany {
+		pi++
+	}
+	return pi == len(p)
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch_Operators(t *testing.T) {
+	t.Parallel()
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	row := MapRow{
+		"name":       "Alice",
+		"age":        int32(30),
+		"score":      4.5,
+		"active":     true,
+		"created_at": created,
+		"deleted_at": nil,
+	}
+	cases := []struct {
+		name string
+		spec Spec
+		want bool
+	}{
+		{"eq", Eq("name", "Alice"), true},
+		{"eq mixed ints", Eq("age", 30), true},
+		{"eq int float", Eq("age", 30.0), true},
+		{"ne", NotEq("name", "Bob"), true},
+		{"gt", Gt("age", 18), true},
+		{"gte", Gte("score", 4.5), true},
+		{"lt", Lt("score", 4), false},
+		{"lte string", Lte("name", "B"), true},
+		{"bool", Eq("active", true), true},
+		{"time", Gt("created_at", created.Add(-time.Hour)), true},
+		{"time string", Eq("created_at", "2024-03-01T12:00:00Z"), true},
+		{"in", In("age", 10, 30), true},
+		{"not in", NotIn("name", "Bob", "Carol"), true},
+		{"in empty", In("age"), false},
+		{"like", Like("name", "A%e"), true},
+		{"like underscore", Like("name", "_lice"), true},
+		{"like case", Like("name", "alice"), false},
+		{"ilike", ILike("name", "ALI%"), true},
+		{"between", Between("age", 18, 65), true},
+		{"between out", Between("score", 5, 10), false},
+		{"is null", IsNull("deleted_at"), true},
+		{"is not null", IsNotNull("name"), true},
+		{"and", And(Eq("name", "Alice"), Gt("age", 40)), false},
+		{"or", Or(Eq("name", "Bob"), Gt("age", 20)), true},
+		{"not", Not(Eq("name", "Bob")), true},
+	}
+	for _, tc := range cases {
+		got, err := Match(tc.spec, row)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestMatch_NullLogic(t *testing.T) {
+	t.Parallel()
+	row := MapRow{"email": nil, "nick": sql.NullString{}, "age": 20}
+	cases := []struct {
+		name string
+		spec Spec
+		want bool
+	}{
+		{"eq null", Eq("email", "x"), false},
+		{"ne null", NotEq("email", "x"), false},
+		{"not eq null", Not(Eq("email", "x")), false},
+		{"valuer null", IsNull("nick"), true},
+		{"not in with null", NotIn("age", 10, nil), false},
+		{"in with null", In("age", 20, nil), true},
+		{"or unknown true", Or(Eq("email", "x"), Eq("age", 20)), true},
+		{"and unknown false", Not(And(Eq("email", "x"), Eq("age", 1))), true},
+		{"like null", Like("email", "%"), false},
+	}
+	for _, tc := range cases {
+		got, err := Match(tc.spec, row)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestMatch_LikeEscape(t *testing.T) {
+	t.Parallel()
+	row := MapRow{"code": "50%_off", "title": "Привет"}
+	if ok, _ := Match(Like("code", `50\%\_off`), row); !ok {
+		t.Error("expected escaped pattern to match")
+	}
+	if ok, _ := Match(Like("code", `50\%x`), row); ok {
+		t.Error("expected escaped pattern not to match")
+	}
+	if ok, _ := Match(Like("title", "Прив__"), row); !ok {
+		t.Error("expected _ to match a single character")
+	}
+}
+
+func TestLikeMatch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		s, pattern string
+		want       bool
+	}{
+		{"", "", true},
+		{"", "%", true},
+		{"", "_", false},
+		{"abc", "a%c", true},
+		{"abc", "%b%", true},
+		{"abc", "a_", false},
+		{"abcbc", "%bc", true},
+		{"abcbd", "a%bc", false},
+		{"mississippi", "m%iss%pi", true},
+		{"mississippi", "m%iss%px", false},
+		{`a\`, `a\\`, true},
+		{`a\`, `a\`, true},
+		{"a%b", `a\%b`, true},
+		{"axb", `a\%b`, false},
+	}
+	for _, tt := range tests {
+		if got := likeMatch(tt.s, tt.pattern); got != tt.want {
+			t.Errorf("likeMatch(%q, %q) = %v, want %v", tt.s, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestLikeMatch_NoExponentialBacktracking(t *testing.T) {
+	t.Parallel()
+	s := strings.Repeat("a", 5000)
+	pattern := strings.Repeat("%a", 50) + "b"
+	done := make(chan bool, 1)
+	go func() { done <- likeMatch(s, pattern) }()
+	select {
+	case got := <-done:
+		if got {
+			t.Error("expected no match")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("likeMatch did not finish in time")
+	}
+}
+
+func TestMatch_Errors(t *testing.T) {
+	t.Parallel()
+	row := MapRow{"name": "Alice", "tags": []string{"a"}}
+	if _, err := Match(Eq("missing", 1), row); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+	if _, err := Match(Raw("1 = 1"), row); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec for raw, got %v", err)
+	}
+	if _, err := Match(Gt("name", 5), row); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec for mismatched types, got %v", err)
+	}
+	if _, err := Match(Like("tags", "a"), row); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec for LIKE on slice, got %v", err)
+	}
+}
+
+func TestMatch_RowFuncAndMatcher(t *testing.T) {
+	t.Parallel()
+	row := RowFunc(func(column string) (any, bool) {
+		if column == "id" {
+			return 7, true
+		}
+		return nil, false
+	})
+	m, ok := Eq("id", 7).(Matcher)
+	if !ok {
+		t.Fatal("expected built-in spec to implement Matcher")
+	}
+	if got, err := m.Match(row); err != nil || !got {
+		t.Errorf("expected match, got %v, %v", got, err)
+	}
+}
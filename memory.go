@@ -0,0 +1,683 @@
+package repository
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Memory[T any] struct {
+	mu      sync.RWMutex
+	dialect Dialect
+	table   Table
+	codec   rowCodec[T]
+	index   map[string]int
+	pkIndex []int
+	keys    []string
+	records map[string]*memoryRecord
+}
+
+type memoryRecord struct {
+	root     []any
+	children map[string][][]any
+	meta     map[string]any
+}
+
+type MemoryOption func(*memoryOptions)
+
+type memoryOptions struct {
+	dialect Dialect
+}
+
+func WithMemoryDialect(d Dialect) MemoryOption {
+	return func(o *memoryOptions) { o.dialect = d }
+}
+
+func NewMemory[T any](mapping Mapping[T], opts ...MemoryOption) *Memory[T] {
+	o := memoryOptions{dialect: Postgres()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	m := mapping.configure(o.dialect)
+	index := make(map[string]int, len(m.table.Columns))
+	for i, col := range m.table.Columns {
+		index[col] = i
+	}
+	pkIndex := make([]int, len(m.table.PrimaryKey))
+	for i, pk := range m.table.PrimaryKey {
+		pos, ok := index[pk]
+		if !ok {
+			pos = -1
+		}
+		pkIndex[i] = pos
+	}
+	return &Memory[T]{
+		dialect: o.dialect,
+		table:   m.table,
+		codec:   m.codec,
+		index:   index,
+		pkIndex: pkIndex,
+		records: make(map[string]*memoryRecord),
+	}
+}
+
+func (m *Memory[T]) Find(_ context.Context, ids ...any) (T, error) {
+	var zero T
+	if len(ids) != len(m.table.PrimaryKey) {
+		return zero, fmt.Errorf("expected %d primary key value(s), got %d",
+			len(m.table.PrimaryKey), len(ids))
+	}
+	key, err := memoryKey(ids)
+	if err != nil {
+		return zero, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rec, ok := m.records[key]
+	if !ok || m.deleted(rec) {
+		return zero, ErrNotFound
+	}
+	return m.codec.decode(rec.root, rec.children)
+}
+
+func (m *Memory[T]) FindBy(ctx context.Context, s Spec) ([]T, error) {
+	s, err := m.prepareSpec(s)
+	if err != nil {
+		return nil, err
+	}
+	return m.selectItems(ctx, s, nil, nil, nil)
+}
+
+func (m *Memory[T]) CountBy(ctx context.Context, s Spec) (int64, error) {
+	s, err := m.prepareSpec(s)
+	if err != nil {
+		return 0, err
+	}
+	return m.count(ctx, s)
+}
+
+func (m *Memory[T]) ExistsBy(ctx context.Context, s Spec) (bool, error) {
+	s, err := m.prepareSpec(s)
+	if err != nil {
+		return false, err
+	}
+	return m.exists(ctx, s)
+}
+
+func (m *Memory[T]) Save(_ context.Context, aggregate T) error {
+	cv := m.codec.encode(aggregate)
+	if len(cv.Root) != len(m.table.Columns) {
+		return fmt.Errorf("expected %d column value(s), got %d", len(m.table.Columns), len(cv.Root))
+	}
+	root, err := driverValues(cv.Root)
+	if err != nil {
+		return err
+	}
+	ids := make([]any, len(m.pkIndex))
+	for i, pos := range m.pkIndex {
+		if pos < 0 {
+			return fmt.Errorf("primary key %s not found in columns of %s", m.table.PrimaryKey[i], m.table.Name)
+		}
+		ids[i] = root[pos]
+	}
+	key, err := memoryKey(ids)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	rec, exists := m.records[key]
+	if !exists {
+		rec = &memoryRecord{children: make(map[string][][]any), meta: make(map[string]any)}
+		if m.table.CreatedAt != "" {
+			rec.meta[m.table.CreatedAt] = now
+		}
+	} else if err := m.bumpVersion(rec, root); err != nil {
+		return err
+	}
+	if err := m.saveChildren(rec, cv.Children); err != nil {
+		return err
+	}
+	if m.table.UpdatedAt != "" {
+		rec.meta[m.table.UpdatedAt] = now
+	}
+	rec.root = root
+	if !exists {
+		m.records[key] = rec
+		m.keys = append(m.keys, key)
+	}
+	return nil
+}
+
+func (m *Memory[T]) Delete(_ context.Context, ids ...any) error {
+	if len(ids) != len(m.table.PrimaryKey) {
+		return fmt.Errorf("expected %d primary key value(s), got %d",
+			len(m.table.PrimaryKey), len(ids))
+	}
+	key, err := memoryKey(ids)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.records[key]
+	if !ok {
+		return nil
+	}
+	if m.table.SoftDelete != "" {
+		if !m.deleted(rec) {
+			rec.meta[m.table.SoftDelete] = time.Now()
+		}
+		return nil
+	}
+	delete(m.records, key)
+	m.keys = slices.DeleteFunc(m.keys, func(k string) bool { return k == key })
+	return nil
+}
+
+func (m *Memory[T]) Query(ctx context.Context) *Query[T] {
+	return &Query[T]{
+		src:     m,
+		ctx:     ctx,
+		forward: true,
+	}
+}
+
+func (m *Memory[T]) NewQuery() *Query[T] {
+	return &Query[T]{src: m, forward: true}
+}
+
+func (m *Memory[T]) queryDialect() Dialect { return m.dialect }
+
+func (m *Memory[T]) primaryKey() []string { return m.table.PrimaryKey }
+
+func (m *Memory[T]) nullable(column string) bool { return slices.Contains(m.table.Nullable, column) }
+
+func (m *Memory[T]) prepareSpec(s Spec) (Spec, error) {
+	s, err := transformSpec(s, func(n Spec) (Spec, error) {
+		h, ok := n.(*hasChildSpec)
+		if !ok {
+			return n, nil
+		}
+		for _, rel := range m.codec.relations {
+			if rel.Table == h.relation {
+				return &childMatchSpec{hasChildSpec: h, relation: rel}, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: unknown relation %q", ErrInvalidSpec, h.relation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, Validate(s)
+}
+
+func (m *Memory[T]) prepareOrders(orders []orderClause) ([]orderClause, error) {
+	for _, o := range orders {
+		if o.rank != nil {
+			return nil, fmt.Errorf("%w: rank order cannot be evaluated in memory", ErrUnsupportedSpec)
+		}
+	}
+	return resolveOrders(orders, nil, m.dialect)
+}
+
+func (m *Memory[T]) cursorValues(item T, orders []orderClause) (map[string]any, error) {
+	return encodeCursorValues(m.codec.encode, m.table.Columns, item, orders)
+}
+
+func (m *Memory[T]) cursorKey() []byte { return nil }
+
+func (m *Memory[T]) selectItems(
+	ctx context.Context, s Spec, orders []orderClause, limit, offset *int64,
+) ([]T, error) {
+	items, _, err := m.selectCounted(ctx, s, orders, limit, offset)
+	return items, err
+}
+
+func (m *Memory[T]) selectCounted(
+	_ context.Context, s Spec, orders []orderClause, limit, offset *int64,
+) ([]T, int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records, err := m.filter(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := m.sort(records, orders); err != nil {
+		return nil, 0, err
+	}
+	total := int64(len(records))
+	if offset != nil {
+		records = records[min(max(*offset, 0), int64(len(records))):]
+	}
+	if limit != nil {
+		records = records[:min(max(*limit, 0), int64(len(records)))]
+	}
+
+	var result []T
+	for _, rec := range records {
+		item, err := m.codec.decode(rec.root, rec.children)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, item)
+	}
+	return result, total, nil
+}
+
+func (m *Memory[T]) count(_ context.Context, s Spec) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records, err := m.filter(s)
+	return int64(len(records)), err
+}
+
+func (m *Memory[T]) exists(ctx context.Context, s Spec) (bool, error) {
+	n, err := m.count(ctx, s)
+	return n > 0, err
+}
+
+func (m *Memory[T]) aggregate(_ context.Context, s Spec, a aggregateQuery) ([][]any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records, err := m.filter(s)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	groups := make(map[string][]Row)
+	for _, rec := range records {
+		row := m.row(rec)
+		values := make([]any, len(a.groupBy))
+		for i, col := range a.groupBy {
+			if values[i], err = rowValue(row, col); err != nil {
+				return nil, err
+			}
+		}
+		key, err := memoryKey(values)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+	if len(a.groupBy) == 0 && len(keys) == 0 {
+		keys = append(keys, "")
+	}
+
+	cols := a.columns()
+	var result []MapRow
+	for _, key := range keys {
+		rows := groups[key]
+		out := make(MapRow, len(cols))
+		for _, col := range a.groupBy {
+			out[col], _ = rowValue(rows[0], col)
+		}
+		for _, agg := range a.aggregates {
+			if out[agg.alias], err = aggregateRows(agg, rows); err != nil {
+				return nil, err
+			}
+		}
+		if a.having != nil {
+			ok, err := Match(a.having, out)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		result = append(result, out)
+	}
+
+	var sortErr error
+	slices.SortStableFunc(result, func(x, y MapRow) int {
+		for _, o := range a.orders {
+			c, err := compareForOrder(x[o.column], y[o.column], o)
+			if err != nil {
+				sortErr = err
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	values := make([][]any, len(result))
+	for i, out := range result {
+		values[i] = make([]any, len(cols))
+		for j, col := range cols {
+			values[i][j] = out[col]
+		}
+	}
+	return values, nil
+}
+
+func (m *Memory[T]) project(
+	_ context.Context, s Spec, p projection, orders []orderClause, limit, offset *int64,
+) ([][]any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records, err := m.filter(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.sort(records, orders); err != nil {
+		return nil, err
+	}
+
+	var result [][]any
+	seen := make(map[string]bool)
+	for _, rec := range records {
+		row := make([]any, len(p.columns))
+		for i, col := range p.columns {
+			if row[i], err = rowValue(m.row(rec), col); err != nil {
+				return nil, err
+			}
+		}
+		if p.distinct {
+			key, err := memoryKey(row)
+			if err != nil {
+				return nil, err
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		result = append(result, row)
+	}
+	if offset != nil {
+		result = result[min(max(*offset, 0), int64(len(result))):]
+	}
+	if limit != nil {
+		result = result[:min(max(*limit, 0), int64(len(result)))]
+	}
+	return result, nil
+}
+
+func aggregateRows(agg Aggregate, rows []Row) (any, error) {
+	if agg.fn == "COUNT" {
+		return int64(len(rows)), nil
+	}
+	var result any
+	var sum float64
+	var intSum, n int64
+	floats := false
+	for _, row := range rows {
+		v, err := rowValue(row, agg.column)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		n++
+		switch agg.fn {
+		case "SUM", "AVG":
+			switch x := v.(type) {
+			case int64:
+				intSum += x
+				sum += float64(x)
+			case float64:
+				sum += x
+				floats = true
+			default:
+				return nil, fmt.Errorf("%w: %s of %T", ErrUnsupportedSpec, agg.fn, v)
+			}
+		case "MIN", "MAX":
+			if result == nil {
+				result = v
+				continue
+			}
+			c, err := compareValues(v, result)
+			if err != nil {
+				return nil, err
+			}
+			if agg.fn == "MIN" && c < 0 || agg.fn == "MAX" && c > 0 {
+				result = v
+			}
+		}
+	}
+	switch {
+	case n == 0:
+		return nil, nil
+	case agg.fn == "AVG":
+		return sum / float64(n), nil
+	case agg.fn == "SUM" && floats:
+		return sum, nil
+	case agg.fn == "SUM":
+		return intSum, nil
+	}
+	return result, nil
+}
+
+func (m *Memory[T]) filter(s Spec) ([]*memoryRecord, error) {
+	var result []*memoryRecord
+	for _, key := range m.keys {
+		rec := m.records[key]
+		if m.deleted(rec) {
+			continue
+		}
+		if s != nil {
+			ok, err := Match(s, m.row(rec))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		result = append(result, rec)
+	}
+	return result, nil
+}
+
+func (m *Memory[T]) sort(records []*memoryRecord, orders []orderClause) error {
+	var sortErr error
+	slices.SortStableFunc(records, func(a, b *memoryRecord) int {
+		for _, o := range orders {
+			var expr Expression = colExpr{name: o.column}
+			if o.expr != nil {
+				expr = o.expr
+			}
+			av, err := exprValue(m.row(a), expr)
+			if err != nil {
+				sortErr = err
+				return 0
+			}
+			bv, err := exprValue(m.row(b), expr)
+			if err != nil {
+				sortErr = err
+				return 0
+			}
+			c, err := compareForOrder(av, bv, o)
+			if err != nil {
+				sortErr = err
+				return 0
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return sortErr
+}
+
+func (m *Memory[T]) row(rec *memoryRecord) Row {
+	return &memoryRow{table: m.table, index: m.index, rec: rec}
+}
+
+type memoryRow struct {
+	table Table
+	index map[string]int
+	rec   *memoryRecord
+}
+
+func (r *memoryRow) Value(column string) (any, bool) {
+	if i, ok := r.index[column]; ok {
+		return r.rec.root[i], true
+	}
+	switch column {
+	case "":
+		return nil, false
+	case r.table.CreatedAt, r.table.UpdatedAt, r.table.SoftDelete:
+		return r.rec.meta[column], true
+	}
+	return nil, false
+}
+
+type childMatchSpec struct {
+	*hasChildSpec
+	relation Relation
+}
+
+func (s *childMatchSpec) validate() error { return nil }
+
+func (s *childMatchSpec) eval(row Row) (truth, error) {
+	r, ok := row.(*memoryRow)
+	if !ok {
+		return truthUnknown, fmt.Errorf("%w: HasChild outside of an in-memory store", ErrUnsupportedSpec)
+	}
+	for _, values := range r.rec.children[s.relation.Table] {
+		child := MapRow{}
+		for i, col := range s.relation.Columns {
+			child[col] = values[i]
+		}
+		if s.where == nil {
+			return truthTrue, nil
+		}
+		matched, err := Match(s.where, child)
+		if err != nil || matched {
+			return truthOf(matched), err
+		}
+	}
+	return truthFalse, nil
+}
+
+func (m *Memory[T]) deleted(rec *memoryRecord) bool {
+	return m.table.SoftDelete != "" && rec.meta[m.table.SoftDelete] != nil
+}
+
+func (m *Memory[T]) bumpVersion(rec *memoryRecord, root []any) error {
+	pos, ok := m.index[m.table.VersionColumn]
+	if m.table.VersionColumn == "" || !ok {
+		return nil
+	}
+	if eq, _ := valuesEqual(rec.root[pos], root[pos]); !eq {
+		return ErrConcurrentModification
+	}
+	switch v := root[pos].(type) {
+	case int64:
+		root[pos] = v + 1
+	case float64:
+		root[pos] = v + 1
+	default:
+		return fmt.Errorf("version column %s: cannot increment %T", m.table.VersionColumn, v)
+	}
+	return nil
+}
+
+func (m *Memory[T]) saveChildren(rec *memoryRecord, children map[string][][]any) error {
+	for _, rel := range m.codec.relations {
+		rows := make([][]any, 0, len(children[rel.Table]))
+		for _, row := range children[rel.Table] {
+			values, err := driverValues(row)
+			if err != nil {
+				return fmt.Errorf("child %s: %w", rel.Table, err)
+			}
+			rows = append(rows, values)
+		}
+		if rel.OnSave == Upsert {
+			rows = upsertChildRows(rel, rec.children[rel.Table], rows)
+		}
+		rec.children[rel.Table] = rows
+	}
+	return nil
+}
+
+func upsertChildRows(rel Relation, existing, rows [][]any) [][]any {
+	pos := slices.Index(rel.Columns, rel.PrimaryKey)
+	if pos < 0 {
+		return append(slices.Clone(existing), rows...)
+	}
+	merged := slices.Clone(existing)
+	for _, row := range rows {
+		i := slices.IndexFunc(merged, func(old []any) bool {
+			eq, _ := valuesEqual(old[pos], row[pos])
+			return eq
+		})
+		if i >= 0 {
+			merged[i] = row
+		} else {
+			merged = append(merged, row)
+		}
+	}
+	return merged
+}
+
+func compareForOrder(a, b any, o orderClause) (int, error) {
+	nullFirst := 1
+	if o.nullsFirst {
+		nullFirst = -1
+	}
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil:
+		return nullFirst, nil
+	case b == nil:
+		return -nullFirst, nil
+	}
+	c, err := compareValues(a, b)
+	if o.dir == Desc {
+		c = -c
+	}
+	return c, err
+}
+
+func driverValues(values []any) ([]any, error) {
+	result := make([]any, len(values))
+	for i, v := range values {
+		dv, err := sqlDriver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %w", i, err)
+		}
+		result[i] = dv
+	}
+	return result, nil
+}
+
+func memoryKey(ids []any) (string, error) {
+	values, err := driverValues(ids)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		parts[i] = fmt.Sprintf("%T:%v", v, v)
+	}
+	return strings.Join(parts, "\x00"), nil
+}
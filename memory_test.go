@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type memUser struct {
+	ID      int
+	Name    string
+	Age     int
+	Version int
+}
+
+var memUserTable = Table{
+	Name:          "users",
+	PrimaryKey:    []string{"id"},
+	Columns:       []string{"id", "name", "age", "version"},
+	VersionColumn: "version",
+	SoftDelete:    "deleted_at",
+	CreatedAt:     "created_at",
+	UpdatedAt:     "updated_at",
+}
+
+func newMemUsers(t *testing.T, users ...memUser) *Memory[memUser] {
+	t.Helper()
+	m := NewMemory(Simple(SimpleConfig[memUser]{
+		Table: memUserTable,
+		Scan: func(sc Scanner) (memUser, error) {
+			var u memUser
+			err := sc.Scan(&u.ID, &u.Name, &u.Age, &u.Version)
+			return u, err
+		},
+		Values: func(u memUser) []any { return []any{u.ID, u.Name, u.Age, u.Version} },
+	}))
+	for _, u := range users {
+		if err := m.Save(context.Background(), u); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return m
+}
+
+func TestMemory_SaveFindDelete(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := newMemUsers(t, memUser{ID: 1, Name: "alice", Age: 30})
+
+	u, err := m.Find(ctx, 1)
+	if err != nil || u.Name != "alice" {
+		t.Fatalf("unexpected: %+v, %v", u, err)
+	}
+	if _, err := m.Find(ctx, 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := m.Find(ctx); err == nil {
+		t.Error("expected arity error")
+	}
+
+	if err := m.Delete(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Find(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected soft-deleted row to be hidden, got %v", err)
+	}
+	if n, _ := m.CountBy(ctx, nil); n != 0 {
+		t.Errorf("expected 0, got %d", n)
+	}
+	if ok, err := m.ExistsBy(ctx, IsNotNull("deleted_at")); err != nil || ok {
+		t.Errorf("soft delete filter must apply to every read, got %v, %v", ok, err)
+	}
+}
+
+func TestMemory_OptimisticVersion(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := newMemUsers(t, memUser{ID: 1, Name: "alice", Version: 1})
+
+	u, _ := m.Find(ctx, 1)
+	u.Name = "alicia"
+	if err := m.Save(ctx, u); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	saved, _ := m.Find(ctx, 1)
+	if saved.Version != 2 || saved.Name != "alicia" {
+		t.Errorf("expected version bump, got %+v", saved)
+	}
+	if err := m.Save(ctx, u); !errors.Is(err, ErrConcurrentModification) {
+		t.Errorf("expected ErrConcurrentModification, got %v", err)
+	}
+}
+
+func TestMemory_FindByAndQuery(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := newMemUsers(t,
+		memUser{ID: 1, Name: "alice", Age: 30},
+		memUser{ID: 2, Name: "bob", Age: 25},
+		memUser{ID: 3, Name: "carol", Age: 35},
+	)
+
+	found, err := m.FindBy(ctx, Gte("age", 30))
+	if err != nil || len(found) != 2 {
+		t.Fatalf("unexpected: %v, %v", found, err)
+	}
+
+	items, err := m.Query(ctx).OrderBy("age", Desc).Offset(1).Limit(1).All()
+	if err != nil || len(items) != 1 || items[0].Name != "alice" {
+		t.Errorf("unexpected: %v, %v", items, err)
+	}
+	first, err := m.Query(ctx).Where(Like("name", "%o%")).OrderBy("name", Asc).First()
+	if err != nil || first.Name != "bob" {
+		t.Errorf("unexpected: %+v, %v", first, err)
+	}
+	if _, err := m.Query(ctx).Where(Eq("name", "zed")).First(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if n, err := m.Query(ctx).Where(Lt("age", 40)).Count(); err != nil || n != 3 {
+		t.Errorf("unexpected count: %d, %v", n, err)
+	}
+	collated, err := m.FindBy(ctx, Collate(Or(Eq("name", "bob"), EqFold("name", "CAROL")), "C"))
+	if err != nil || len(collated) != 2 {
+		t.Errorf("Collate must evaluate the inner spec, got %v, %v", collated, err)
+	}
+	if _, err := m.FindBy(ctx, Eq("missing", 1)); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+	if _, err := m.Query(ctx).OrderBy("age", "sideways").All(); !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("expected ErrInvalidDirection, got %v", err)
+	}
+}
+
+func TestMemory_KeysetPage(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := newMemUsers(t,
+		memUser{ID: 1, Name: "a", Age: 20},
+		memUser{ID: 2, Name: "b", Age: 20},
+		memUser{ID: 3, Name: "c", Age: 10},
+	)
+	extract := func(u memUser) map[string]any { return map[string]any{"age": u.Age, "id": u.ID} }
+
+	page, err := m.Query(ctx).OrderBy("age", Desc).PageSize(2).Page(extract)
+	if err != nil || len(page.Items) != 2 || !page.HasMore {
+		t.Fatalf("unexpected first page: %+v, %v", page, err)
+	}
+	next, err := m.Query(ctx).OrderBy("age", Desc).PageSize(2).After(page.NextCursor).Page(extract)
+	if err != nil || len(next.Items) != 1 || next.Items[0].ID != 3 || next.HasMore {
+		t.Errorf("unexpected second page: %+v, %v", next, err)
+	}
+}
+
+func TestMemory_CompositeChildren(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := NewMemory(Composite(CompositeConfig[string, *tSnap]{
+		Table:     compositeTable,
+		Relations: []Relation{itemsRelation},
+		ScanRoot:  compositeScanRoot,
+		ScanChild: compositeScanChild,
+		Build: func(s *tSnap) (string, error) {
+			return s.id + ":" + string(rune('0'+len(s.items))), nil
+		},
+		Decompose: func(s string) CompositeValues {
+			return CompositeValues{
+				Root:     []any{s, "name"},
+				Children: map[string][][]any{"items": {{s + "-1", s, "x"}, {s + "-2", s, "y"}}},
+			}
+		},
+		ExtractPK: compositeExtractPK,
+	}))
+	if err := m.Save(ctx, "o1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Save(ctx, "o1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := m.Find(ctx, "o1")
+	if err != nil || got != "o1:2" {
+		t.Errorf("expected children replaced on save, got %q, %v", got, err)
+	}
+}
+
+func TestMemory_DialectNullOrder(t *testing.T) {
+	t.Parallel()
+	type row struct {
+		ID   int
+		Note *string
+	}
+	mapping := Simple(SimpleConfig[row]{
+		Table: Table{Name: "notes", PrimaryKey: []string{"id"}, Columns: []string{"id", "note"}},
+		Scan: func(sc Scanner) (row, error) {
+			var r row
+			err := sc.Scan(&r.ID, &r.Note)
+			return r, err
+		},
+		Values: func(r row) []any { return []any{r.ID, r.Note} },
+	})
+	note := "a"
+	tests := []struct {
+		name  string
+		store *Memory[row]
+		first int
+	}{
+		{"postgres", NewMemory(mapping), 2},
+		{"mysql", NewMemory(mapping, WithMemoryDialect(MySQL())), 1},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if err := tt.store.Save(ctx, row{ID: 1}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+		if err := tt.store.Save(ctx, row{ID: 2, Note: &note}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+		items, err := tt.store.Query(ctx).OrderBy("note", Asc).All()
+		if err != nil || len(items) != 2 || items[0].ID != tt.first {
+			t.Errorf("%s: unexpected: %+v, %v", tt.name, items, err)
+		}
+		groups, err := tt.store.Query(ctx).GroupBy("note").Aggregate(CountOf("n")).OrderBy("note", Asc).All()
+		if err != nil || len(groups) != 2 || (groups[0]["note"] == nil) != (tt.first == 1) {
+			t.Errorf("%s: unexpected group order: %v, %v", tt.name, groups, err)
+		}
+	}
+}
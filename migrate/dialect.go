@@ -0,0 +1,154 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/shuldan/repository"
+)
+
+type Dialect interface {
+	repository.Dialect
+	createTableSQL(table string) string
+	tableExistsSQL(table string) (string, []any)
+	lock(ctx context.Context, conn *sql.Conn, table string) error
+	unlock(ctx context.Context, conn *sql.Conn, table string) error
+}
+
+var lockPollInterval = 100 * time.Millisecond
+
+func quoteTable(d repository.Dialect, name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = d.QuoteIdent(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func unqualified(name string) string {
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+func createVersionTable(d repository.Dialect, table, versionType string) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ("+
+		"version %s PRIMARY KEY, "+
+		"name VARCHAR(255) NOT NULL, "+
+		"applied_at TIMESTAMP NOT NULL)", quoteTable(d, table), versionType)
+}
+
+type postgresDialect struct{ repository.Dialect }
+
+func Postgres() Dialect { return &postgresDialect{Dialect: repository.Postgres()} }
+
+//nolint:unused
+func (d *postgresDialect) createTableSQL(table string) string {
+	return createVersionTable(d, table, "BIGINT")
+}
+
+//nolint:unused
+func (d *postgresDialect) tableExistsSQL(table string) (string, []any) {
+	return "SELECT to_regclass($1) IS NOT NULL", []any{quoteTable(d, table)}
+}
+
+//nolint:unused
+func (d *postgresDialect) lock(ctx context.Context, conn *sql.Conn, table string) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryKey(table))
+	return err
+}
+
+//nolint:unused
+func (d *postgresDialect) unlock(ctx context.Context, conn *sql.Conn, table string) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryKey(table))
+	return err
+}
+
+func advisoryKey(table string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(table))
+	return int64(h.Sum64() >> 1)
+}
+
+type mysqlDialect struct{ repository.Dialect }
+
+func MySQL() Dialect { return &mysqlDialect{Dialect: repository.MySQL()} }
+
+//nolint:unused
+func (d *mysqlDialect) createTableSQL(table string) string {
+	return createVersionTable(d, table, "BIGINT")
+}
+
+//nolint:unused
+func (d *mysqlDialect) tableExistsSQL(table string) (string, []any) {
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		return "SELECT COUNT(*) > 0 FROM information_schema.tables " +
+			"WHERE table_schema = ? AND table_name = ?", []any{schema, name}
+	}
+	return "SELECT COUNT(*) > 0 FROM information_schema.tables " +
+		"WHERE table_schema = DATABASE() AND table_name = ?", []any{table}
+}
+
+//nolint:unused
+func (d *mysqlDialect) lock(ctx context.Context, conn *sql.Conn, table string) error {
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", table).Scan(&acquired); err != nil {
+		return err
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return ErrLocked
+	}
+	return nil
+}
+
+//nolint:unused
+func (d *mysqlDialect) unlock(ctx context.Context, conn *sql.Conn, table string) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", table)
+	return err
+}
+
+type sqliteDialect struct{ repository.Dialect }
+
+func SQLite() Dialect { return &sqliteDialect{Dialect: repository.SQLite()} }
+
+//nolint:unused
+func (d *sqliteDialect) createTableSQL(table string) string {
+	return createVersionTable(d, table, "INTEGER")
+}
+
+//nolint:unused
+func (d *sqliteDialect) tableExistsSQL(table string) (string, []any) {
+	return "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?", []any{unqualified(table)}
+}
+
+//nolint:unused
+func (d *sqliteDialect) lock(ctx context.Context, conn *sql.Conn, table string) error {
+	lockTable := quoteTable(d, table+"_lock")
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id INTEGER PRIMARY KEY, locked_at INTEGER NOT NULL)", lockTable)
+	if _, err := conn.ExecContext(ctx, create); err != nil {
+		return err
+	}
+	insert := fmt.Sprintf("INSERT OR IGNORE INTO %s (id, locked_at) VALUES (1, ?)", lockTable)
+	for {
+		result, err := conn.ExecContext(ctx, insert, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil || n == 1 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrLocked, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+//nolint:unused
+func (d *sqliteDialect) unlock(ctx context.Context, conn *sql.Conn, table string) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = 1", quoteTable(d, table+"_lock")))
+	return err
+}
@@ -0,0 +1,10 @@
+package migrate
+
+import "errors"
+
+var (
+	ErrInvalidMigration = errors.New("invalid migration")
+	ErrIrreversible     = errors.New("migration is irreversible")
+	ErrUnknownVersion   = errors.New("unknown applied version")
+	ErrLocked           = errors.New("migrations are locked by another runner")
+)
@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+type Func func(ctx context.Context, tx *sql.Tx) error
+
+type Migration struct {
+	Version int64
+	Name    string
+
+	UpSQL   string
+	DownSQL string
+
+	UpFunc   Func
+	DownFunc Func
+}
+
+type Direction string
+
+const (
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+type Step struct {
+	Migration Migration
+	Direction Direction
+}
+
+func (m Migration) reversible() bool {
+	return m.DownFunc != nil || m.DownSQL != ""
+}
+
+func (m Migration) run(ctx context.Context, tx *sql.Tx, dir Direction) error {
+	fn, query := m.UpFunc, m.UpSQL
+	if dir == Down {
+		fn, query = m.DownFunc, m.DownSQL
+	}
+	if fn != nil {
+		return fn(ctx, tx)
+	}
+	if query == "" {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, query)
+	return err
+}
+
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("%w: version %d must be positive", ErrInvalidMigration, m.Version)
+		}
+		if m.UpFunc == nil && m.UpSQL == "" {
+			return nil, fmt.Errorf("%w: version %d has no up migration", ErrInvalidMigration, m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("%w: duplicate version %d", ErrInvalidMigration, m.Version)
+		}
+	}
+	return sorted, nil
+}
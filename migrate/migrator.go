@@ -0,0 +1,257 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shuldan/repository"
+)
+
+const (
+	DefaultTable = "schema_migrations"
+	Latest       = int64(math.MaxInt64)
+)
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	table      string
+	migrations []Migration
+	logger     repository.Logger
+}
+
+func New(db *sql.DB, dialect Dialect, migrations ...Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		table:      DefaultTable,
+		migrations: migrations,
+	}
+}
+
+func (m *Migrator) WithTable(name string) *Migrator {
+	copy := *m
+	copy.table = name
+	return &copy
+}
+
+func (m *Migrator) WithLogger(l repository.Logger) *Migrator {
+	copy := *m
+	copy.logger = l
+	return &copy
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, Latest)
+}
+
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return nil
+		}
+		sorted, err := sortMigrations(m.migrations)
+		if err != nil {
+			return err
+		}
+		step, err := downStep(indexMigrations(sorted), applied[len(applied)-1])
+		if err != nil {
+			return err
+		}
+		return m.run(ctx, conn, []Step{step})
+	})
+}
+
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.migrate(ctx, conn, applied, version)
+	})
+}
+
+func (m *Migrator) Plan(ctx context.Context, version int64) ([]Step, error) {
+	var exists bool
+	query, args := m.dialect.tableExistsSQL(m.table)
+	if err := m.db.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		return nil, err
+	}
+	var applied []int64
+	if exists {
+		var err error
+		if applied, err = m.applied(ctx, m.db); err != nil {
+			return nil, err
+		}
+	}
+	return m.plan(applied, version)
+}
+
+func (m *Migrator) Applied(ctx context.Context) ([]int64, error) {
+	if _, err := m.db.ExecContext(ctx, m.dialect.createTableSQL(m.table)); err != nil {
+		return nil, err
+	}
+	return m.applied(ctx, m.db)
+}
+
+func (m *Migrator) plan(applied []int64, target int64) ([]Step, error) {
+	sorted, err := sortMigrations(m.migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	known := indexMigrations(sorted)
+	done := make(map[int64]bool, len(applied))
+	for _, v := range applied {
+		done[v] = true
+	}
+
+	var steps []Step
+	for i := len(applied) - 1; i >= 0; i-- {
+		v := applied[i]
+		if v <= target {
+			continue
+		}
+		step, err := downStep(known, v)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	for _, mig := range sorted {
+		if mig.Version <= target && !done[mig.Version] {
+			steps = append(steps, Step{Migration: mig, Direction: Up})
+		}
+	}
+	return steps, nil
+}
+
+func indexMigrations(sorted []Migration) map[int64]Migration {
+	known := make(map[int64]Migration, len(sorted))
+	for _, mig := range sorted {
+		known[mig.Version] = mig
+	}
+	return known
+}
+
+func downStep(known map[int64]Migration, version int64) (Step, error) {
+	mig, ok := known[version]
+	if !ok {
+		return Step{}, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	if !mig.reversible() {
+		return Step{}, fmt.Errorf("%w: %d", ErrIrreversible, version)
+	}
+	return Step{Migration: mig, Direction: Down}, nil
+}
+
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, applied []int64, target int64) error {
+	steps, err := m.plan(applied, target)
+	if err != nil {
+		return err
+	}
+	return m.run(ctx, conn, steps)
+}
+
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, steps []Step) error {
+	for _, step := range steps {
+		if err := m.apply(ctx, conn, step); err != nil {
+			return fmt.Errorf("migration %d (%s) %s: %w",
+				step.Migration.Version, step.Migration.Name, step.Direction, err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, step Step) error {
+	start := time.Now()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := m.applyTx(ctx, tx, step); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if m.logger != nil {
+		m.logger.Info("migration applied",
+			"version", step.Migration.Version,
+			"name", step.Migration.Name,
+			"direction", string(step.Direction),
+			"duration", time.Since(start).String(),
+		)
+	}
+	return nil
+}
+
+func (m *Migrator) applyTx(ctx context.Context, tx *sql.Tx, step Step) error {
+	if err := step.Migration.run(ctx, tx, step.Direction); err != nil {
+		return err
+	}
+	d := m.dialect
+	if step.Direction == Down {
+		query := fmt.Sprintf("DELETE FROM %s WHERE version = %s", quoteTable(d, m.table), d.Placeholder(1))
+		_, err := tx.ExecContext(ctx, query, step.Migration.Version)
+		return err
+	}
+	query := fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)",
+		quoteTable(d, m.table), d.Placeholder(1), d.Placeholder(2), d.Now())
+	_, err := tx.ExecContext(ctx, query, step.Migration.Version, step.Migration.Name)
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context, exec repository.Executor) ([]int64, error) {
+	rows, err := exec.QueryContext(ctx, fmt.Sprintf("SELECT version FROM %s", quoteTable(m.dialect, m.table)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var versions []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, rows.Err()
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(*sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTableSQL(m.table)); err != nil {
+		return err
+	}
+	if err := m.dialect.lock(ctx, conn, m.table); err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := m.dialect.unlock(context.WithoutCancel(ctx), conn, m.table); unlockErr != nil {
+			err = errors.Join(err, unlockErr)
+		}
+	}()
+
+	return fn(conn)
+}
@@ -0,0 +1,340 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMigrator_Up_AppliesAllInOrder(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := conn.applied(); !reflect.DeepEqual(got, []int64{1, 2, 3}) {
+		t.Errorf("expected [1 2 3], got %v", got)
+	}
+
+	var order []string
+	for _, s := range conn.statements() {
+		switch s {
+		case testMigrations[0].UpSQL, testMigrations[1].UpSQL, testMigrations[2].UpSQL:
+			order = append(order, s)
+		}
+	}
+	want := []string{testMigrations[1].UpSQL, testMigrations[0].UpSQL, testMigrations[2].UpSQL}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("unexpected order: %v", order)
+	}
+}
+
+func TestMigrator_Up_LocksAndUnlocks(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stmts := conn.statements()
+	if !containsStatement(stmts, "SELECT pg_advisory_lock($1)") {
+		t.Error("expected advisory lock")
+	}
+	if stmts[len(stmts)-1] != "SELECT pg_advisory_unlock($1)" {
+		t.Errorf("expected unlock last, got %q", stmts[len(stmts)-1])
+	}
+}
+
+func TestMigrator_Up_SkipsApplied(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn(1, 2)
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if containsStatement(conn.statements(), testMigrations[1].UpSQL) {
+		t.Error("applied migration should not run again")
+	}
+	if got := conn.applied(); len(got) != 3 {
+		t.Errorf("expected 3 applied, got %v", got)
+	}
+}
+
+func TestMigrator_Up_LockError(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	conn.lockErr = fmt.Errorf("lock failed")
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	if err := m.Up(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(conn.applied()) != 0 {
+		t.Error("nothing should be applied")
+	}
+}
+
+func TestMigrator_Up_MySQLLockNotAcquired(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	conn.lockErr = fmt.Errorf("busy")
+	m := New(newFakeDB(t, conn), MySQL(), testMigrations...)
+	if err := m.Up(context.Background()); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+}
+
+func TestMigrator_Up_FailureRollsBack(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	conn.failOn = "ALTER TABLE users ADD"
+	m := New(newFakeDB(t, conn), SQLite(), testMigrations...)
+
+	err := m.Up(context.Background())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got := conn.applied(); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("expected only [1] applied, got %v", got)
+	}
+	if !containsStatement(conn.statements(), "ROLLBACK") {
+		t.Error("expected rollback")
+	}
+	if !containsStatement(conn.statements(), `DELETE FROM "schema_migrations_lock"`) {
+		t.Error("expected lock release after failure")
+	}
+}
+
+func TestMigrator_SQLiteLockWaits(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	conn.lockHeld = true
+	m := New(newFakeDB(t, conn), SQLite(), testMigrations...)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	if err := m.Up(ctx); !errors.Is(err, ErrLocked) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrLocked after waiting, got %v", err)
+	}
+	if len(conn.applied()) != 0 {
+		t.Error("nothing should be applied while the lock is held")
+	}
+	if containsStatement(conn.statements(), `DELETE FROM "schema_migrations_lock"`) {
+		t.Error("a waiting runner must not release a lock it does not hold")
+	}
+}
+
+func TestMigrator_QuotesTableNames(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	m := New(newFakeDB(t, conn), SQLite(), testMigrations...).WithTable("main.order")
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stmts := conn.statements()
+	for _, prefix := range []string{
+		`CREATE TABLE IF NOT EXISTS "main"."order" (`,
+		`CREATE TABLE IF NOT EXISTS "main"."order_lock" (`,
+		`INSERT OR IGNORE INTO "main"."order_lock" (id, locked_at)`,
+		`SELECT version FROM "main"."order"`,
+		`INSERT INTO "main"."order" (version, name, applied_at)`,
+		`DELETE FROM "main"."order_lock" WHERE id = 1`,
+	} {
+		if !containsStatement(stmts, prefix) {
+			t.Errorf("expected statement %s", prefix)
+		}
+	}
+}
+
+func TestMigrator_Down_RollsBackLatest(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn(1, 2)
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	if err := m.Down(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := conn.applied(); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("expected [1], got %v", got)
+	}
+	if !containsStatement(conn.statements(), "ALTER TABLE users DROP email") {
+		t.Error("expected down SQL")
+	}
+}
+
+func TestMigrator_Down_OnlyLastApplied(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn(2, 3)
+	migs := []Migration{
+		{Version: 1, Name: "one", UpSQL: "CREATE TABLE one (id TEXT)", DownSQL: "DROP TABLE one"},
+		{Version: 2, Name: "two", UpSQL: "CREATE TABLE two (id TEXT)", DownSQL: "DROP TABLE two"},
+		{Version: 3, Name: "three", UpSQL: "CREATE TABLE three (id TEXT)", DownSQL: "DROP TABLE three"},
+	}
+	m := New(newFakeDB(t, conn), Postgres(), migs...)
+	if err := m.Down(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := conn.applied(); !reflect.DeepEqual(got, []int64{2}) {
+		t.Errorf("expected [2], got %v", got)
+	}
+	if containsStatement(conn.statements(), "CREATE TABLE one") {
+		t.Error("down must not apply pending migrations")
+	}
+}
+
+func TestMigrator_Down_Irreversible(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn(1, 2, 3)
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	if err := m.Down(context.Background()); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("expected ErrIrreversible, got %v", err)
+	}
+}
+
+func TestMigrator_Down_NothingApplied(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	if err := m.Down(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMigrator_To_Target(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	if err := m.To(context.Background(), 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := conn.applied(); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("expected [1 2], got %v", got)
+	}
+	if err := m.To(context.Background(), 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := conn.applied(); len(got) != 0 {
+		t.Errorf("expected nothing applied, got %v", got)
+	}
+}
+
+func TestMigrator_To_UnknownApplied(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn(1, 9)
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	if err := m.To(context.Background(), 1); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("expected ErrUnknownVersion, got %v", err)
+	}
+}
+
+func TestMigrator_FuncMigration(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	called := false
+	mig := Migration{Version: 1, Name: "func", UpFunc: func(ctx context.Context, tx *sql.Tx) error {
+		called = true
+		_, err := tx.ExecContext(ctx, "UPDATE users SET name = 'x'")
+		return err
+	}}
+	m := New(newFakeDB(t, conn), Postgres(), mig)
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Error("up func was not called")
+	}
+}
+
+func TestMigrator_Plan_DryRun(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn(1)
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	steps, err := m.Plan(context.Background(), Latest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 2 || steps[0].Migration.Version != 2 || steps[1].Migration.Version != 3 {
+		t.Errorf("unexpected plan: %+v", steps)
+	}
+	if steps[0].Direction != Up {
+		t.Errorf("expected up, got %s", steps[0].Direction)
+	}
+	if got := conn.applied(); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("plan must not apply migrations, got %v", got)
+	}
+	if containsStatement(conn.statements(), "SELECT pg_advisory_lock") {
+		t.Error("plan must not take the lock")
+	}
+}
+
+func TestMigrator_Plan_NoTable(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn()
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	steps, err := m.Plan(context.Background(), Latest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 3 {
+		t.Errorf("expected 3 steps, got %d", len(steps))
+	}
+	if containsStatement(conn.statements(), "CREATE TABLE") {
+		t.Error("plan must not create the version table")
+	}
+}
+
+func TestMigrator_Plan_Down(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn(1, 2)
+	m := New(newFakeDB(t, conn), Postgres(), testMigrations...)
+	steps, err := m.Plan(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 2 || steps[0].Migration.Version != 2 || steps[0].Direction != Down {
+		t.Errorf("unexpected plan: %+v", steps)
+	}
+}
+
+func TestMigrator_Applied(t *testing.T) {
+	t.Parallel()
+	conn := newFakeConn(2, 1)
+	m := New(newFakeDB(t, conn), SQLite(), testMigrations...)
+	got, err := m.Applied(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("expected [1 2], got %v", got)
+	}
+}
+
+func TestMigrator_InvalidMigrations(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		migs []Migration
+	}{
+		{"duplicate", []Migration{{Version: 1, UpSQL: "a"}, {Version: 1, UpSQL: "b"}}},
+		{"zero version", []Migration{{Version: 0, UpSQL: "a"}}},
+		{"no up", []Migration{{Version: 1, DownSQL: "a"}}},
+	}
+	for _, tt := range tests {
+		conn := newFakeConn()
+		m := New(newFakeDB(t, conn), Postgres(), tt.migs...)
+		if err := m.Up(context.Background()); !errors.Is(err, ErrInvalidMigration) {
+			t.Errorf("%s: expected ErrInvalidMigration, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestMigrator_WithTable(t *testing.T) {
+	t.Parallel()
+	m := New(nil, Postgres())
+	custom := m.WithTable("custom_versions")
+	if m.table != DefaultTable || custom.table != "custom_versions" {
+		t.Error("WithTable must return a modified copy")
+	}
+}
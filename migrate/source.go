@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+func FromFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	bases := make(map[int64]string)
+	var order []int64
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		var direction Direction
+		var base string
+		switch {
+		case strings.HasSuffix(name, upSuffix):
+			direction, base = Up, strings.TrimSuffix(name, upSuffix)
+		case strings.HasSuffix(name, downSuffix):
+			direction, base = Down, strings.TrimSuffix(name, downSuffix)
+		default:
+			continue
+		}
+
+		version, label, err := parseFileName(base)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidMigration, name, err)
+		}
+		if prev, ok := bases[version]; ok && prev != base {
+			return nil, fmt.Errorf("%w: duplicate version %d in %s and %s", ErrInvalidMigration, version, prev, base)
+		}
+		bases[version] = base
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+			order = append(order, version)
+		}
+		if direction == Up {
+			m.UpSQL = string(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(order))
+	for _, v := range order {
+		result = append(result, *byVersion[v])
+	}
+	return result, nil
+}
+
+func parseFileName(base string) (int64, string, error) {
+	num, label, _ := strings.Cut(base, "_")
+	version, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("version prefix %q is not a number", num)
+	}
+	return version, label, nil
+}
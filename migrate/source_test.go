@@ -0,0 +1,60 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFromFS_PairsUpAndDown(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"sql/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id TEXT)")},
+		"sql/0001_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+		"sql/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email TEXT")},
+		"sql/README.md":                  {Data: []byte("ignored")},
+	}
+	migs, err := FromFS(fsys, "sql")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migs) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(migs))
+	}
+	first := migs[0]
+	if first.Version != 1 || first.Name != "create_users" {
+		t.Errorf("unexpected migration: %+v", first)
+	}
+	if first.UpSQL != "CREATE TABLE users (id TEXT)" || first.DownSQL != "DROP TABLE users" {
+		t.Errorf("unexpected SQL: %+v", first)
+	}
+	if migs[1].reversible() {
+		t.Error("migration without down file must be irreversible")
+	}
+}
+
+func TestFromFS_InvalidName(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{"m/abc_bad.up.sql": {Data: []byte("SELECT 1")}}
+	if _, err := FromFS(fsys, "m"); !errors.Is(err, ErrInvalidMigration) {
+		t.Errorf("expected ErrInvalidMigration, got %v", err)
+	}
+}
+
+func TestFromFS_DuplicateVersion(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"m/001_create_users.up.sql": {Data: []byte("SELECT 1")},
+		"m/1_add_email.up.sql":      {Data: []byte("SELECT 2")},
+	}
+	if _, err := FromFS(fsys, "m"); !errors.Is(err, ErrInvalidMigration) {
+		t.Errorf("expected ErrInvalidMigration, got %v", err)
+	}
+}
+
+func TestFromFS_MissingDir(t *testing.T) {
+	t.Parallel()
+	if _, err := FromFS(fstest.MapFS{}, "missing"); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,180 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	sqlDriver "database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeConn struct {
+	mu        sync.Mutex
+	versions  map[int64]bool
+	tableMade bool
+	statement []string
+	failOn    string
+	lockErr   error
+	lockHeld  bool
+}
+
+func newFakeConn(applied ...int64) *fakeConn {
+	c := &fakeConn{versions: make(map[int64]bool)}
+	for _, v := range applied {
+		c.versions[v] = true
+		c.tableMade = true
+	}
+	return c
+}
+
+func (c *fakeConn) Prepare(query string) (sqlDriver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (sqlDriver.Tx, error) {
+	c.record("BEGIN")
+	return &fakeTx{conn: c}, nil
+}
+
+func (c *fakeConn) record(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statement = append(c.statement, query)
+}
+
+func (c *fakeConn) statements() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]string, len(c.statement))
+	copy(out, c.statement)
+	return out
+}
+
+func (c *fakeConn) applied() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []int64
+	for v := range c.versions {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+type fakeTx struct{ conn *fakeConn }
+
+func (t *fakeTx) Commit() error   { t.conn.record("COMMIT"); return nil }
+func (t *fakeTx) Rollback() error { t.conn.record("ROLLBACK"); return nil }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []sqlDriver.Value) (sqlDriver.Result, error) {
+	c := s.conn
+	c.record(s.query)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.failOn != "" && strings.Contains(s.query, c.failOn):
+		return nil, fmt.Errorf("exec failed: %s", s.query)
+	case strings.Contains(s.query, "pg_advisory_lock") && c.lockErr != nil:
+		return nil, c.lockErr
+	case strings.HasPrefix(s.query, `CREATE TABLE IF NOT EXISTS "schema_migrations" (`):
+		c.tableMade = true
+	case strings.HasPrefix(s.query, `INSERT INTO "schema_migrations" (`):
+		c.versions[args[0].(int64)] = true
+	case strings.HasPrefix(s.query, `DELETE FROM "schema_migrations" WHERE version`):
+		delete(c.versions, args[0].(int64))
+	case strings.HasPrefix(s.query, "INSERT OR IGNORE INTO") && c.lockHeld:
+		return sqlDriver.RowsAffected(0), nil
+	}
+	return sqlDriver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(_ []sqlDriver.Value) (sqlDriver.Rows, error) {
+	c := s.conn
+	c.record(s.query)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "SELECT version FROM"):
+		rows := &fakeRows{columns: []string{"version"}}
+		for v := range c.versions {
+			rows.data = append(rows.data, []sqlDriver.Value{v})
+		}
+		return rows, nil
+	case strings.Contains(s.query, "GET_LOCK"):
+		if c.lockErr != nil {
+			return &fakeRows{columns: []string{"lock"}, data: [][]sqlDriver.Value{{int64(0)}}}, nil
+		}
+		return &fakeRows{columns: []string{"lock"}, data: [][]sqlDriver.Value{{int64(1)}}}, nil
+	default:
+		return &fakeRows{columns: []string{"exists"}, data: [][]sqlDriver.Value{{c.tableMade}}}, nil
+	}
+}
+
+type fakeRows struct {
+	columns []string
+	data    [][]sqlDriver.Value
+	pos     int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []sqlDriver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.pos])
+	r.pos++
+	return nil
+}
+
+type fakeConnector struct{ conn *fakeConn }
+
+func (c *fakeConnector) Connect(_ context.Context) (sqlDriver.Conn, error) { return c.conn, nil }
+func (c *fakeConnector) Driver() sqlDriver.Driver                          { return &fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (d *fakeDriver) Open(_ string) (sqlDriver.Conn, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func newFakeDB(t *testing.T, conn *fakeConn) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(&fakeConnector{conn: conn})
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+var testMigrations = []Migration{
+	{Version: 2, Name: "add_email", UpSQL: "ALTER TABLE users ADD email TEXT", DownSQL: "ALTER TABLE users DROP email"},
+	{Version: 1, Name: "create_users", UpSQL: "CREATE TABLE users (id TEXT)", DownSQL: "DROP TABLE users"},
+	{Version: 3, Name: "seed", UpSQL: "INSERT INTO users VALUES ('root')"},
+}
+
+func containsStatement(stmts []string, prefix string) bool {
+	for _, s := range stmts {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
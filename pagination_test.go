@@ -0,0 +1,45 @@
+package repository_test
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/shuldan/repository"
+	"github.com/shuldan/repository/repositorytest"
+)
+
+func TestRepository_PaginateWindow(t *testing.T) {
+	t.Parallel()
+	conn := &repositorytest.Conn{Queries: []repositorytest.QueryResult{
+		{Columns: []string{"id", "count"}, Rows: [][]sqlDriver.Value{{"c", int64(7)}, {"d", int64(7)}}},
+		{Columns: []string{"id", "count"}},
+		{Columns: []string{"count"}, Rows: [][]sqlDriver.Value{{int64(7)}}},
+	}}
+	rec := repositorytest.NewRecorder(nil)
+	repo := repository.New(repositorytest.NewDB(t, conn), repository.Postgres(),
+		repository.Simple(repository.SimpleConfig[string]{
+			Table: repository.Table{Name: "items", PrimaryKey: []string{"id"}, Columns: []string{"id"}},
+			Scan: func(sc repository.Scanner) (string, error) {
+				var s string
+				return s, sc.Scan(&s)
+			},
+			Values: func(s string) []any { return []any{s} },
+		})).WithExecutor(rec.Wrap)
+	ctx := context.Background()
+
+	page, err := repo.Query(ctx).PaginateWindow(2, 2)
+	if err != nil || !reflect.DeepEqual(page.Items, []string{"c", "d"}) || page.Total != 7 || page.TotalPages != 4 {
+		t.Fatalf("unexpected page: %+v, %v", page, err)
+	}
+	want := `SELECT "id", COUNT(*) OVER () FROM "items" ORDER BY "id" ASC LIMIT $1 OFFSET $2`
+	if calls := rec.Calls(); len(calls) != 1 || calls[0].SQL != want {
+		t.Errorf("expected a single windowed query, got %v", calls)
+	}
+
+	beyond, err := repo.Query(ctx).PaginateWindow(9, 2)
+	if err != nil || len(beyond.Items) != 0 || beyond.Total != 7 {
+		t.Errorf("page beyond the end must fall back to COUNT, got %+v, %v", beyond, err)
+	}
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+type projection struct {
+	columns  []string
+	distinct bool
+}
+
+func (q *Query[T]) Distinct() *Query[T] { c := q.Clone(); c.distinct = true; return c }
+
+func Select[T, R any](q *Query[T], columns []string, scan func(Scanner) (R, error)) ([]R, error) {
+	return SelectContext(q.context(), q, columns, scan)
+}
+
+func SelectContext[T, R any](
+	ctx context.Context, q *Query[T], columns []string, scan func(Scanner) (R, error),
+) ([]R, error) {
+	rows, err := q.project(ctx, columns)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]R, len(rows))
+	for i, row := range rows {
+		if result[i], err = scan(&valuesScanner{values: row}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func Pluck[T, V any](q *Query[T], column string) ([]V, error) {
+	return PluckContext[T, V](q.context(), q, column)
+}
+
+func PluckContext[T, V any](ctx context.Context, q *Query[T], column string) ([]V, error) {
+	rows, err := q.project(ctx, []string{column})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]V, len(rows))
+	for i, row := range rows {
+		if err := convertAssign(&result[i], row[0]); err != nil {
+			return nil, fmt.Errorf("pluck %s: %w", column, err)
+		}
+	}
+	return result, nil
+}
+
+func (q *Query[T]) project(ctx context.Context, columns []string) ([][]any, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: no columns to select", ErrInvalidSpec)
+	}
+	for _, col := range columns {
+		if err := checkIdent("column", col); err != nil {
+			return nil, err
+		}
+	}
+	if q.distinct {
+		for _, o := range q.orderCols {
+			if o.column == "" || !slices.Contains(columns, o.column) {
+				return nil, fmt.Errorf("%w: with Distinct, order only by selected columns", ErrInvalidSpec)
+			}
+		}
+	}
+	spec, orders, err := q.prepareQuery()
+	if err != nil {
+		return nil, err
+	}
+	p := projection{columns: columns, distinct: q.distinct}
+	return q.src.project(ctx, spec, p, orders, q.limit, q.offset)
+}
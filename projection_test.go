@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestProjectSQL(t *testing.T) {
+	t.Parallel()
+	tbl := Table{Name: "users", PrimaryKey: []string{"id"}, Columns: []string{"id", "email", "user_name"},
+		SoftDelete: "deleted_at"}
+	repo := newSimpleTestRepo(t, &testConn{}, tbl)
+	limit := int64(10)
+
+	query, args, err := repo.projectSQL(Eq("id", 1), projection{columns: []string{"email", "id"}, distinct: true},
+		[]orderClause{{column: "email", dir: Asc}}, &limit, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `SELECT DISTINCT "email", "id" FROM "users" WHERE ("deleted_at" IS NULL) AND ("id" = $1)` +
+		` ORDER BY "email" ASC LIMIT $2`
+	if query != want || !reflect.DeepEqual(args, []any{1, int64(10)}) {
+		t.Errorf("got %s %v", query, args)
+	}
+
+	aliased := repo.WithColumnWhitelist(ColumnWhitelist{Aliases: map[string]string{"name": "user_name"}})
+	if query, _, _ := aliased.projectSQL(nil, projection{columns: []string{"name"}}, nil, nil, nil); query !=
+		`SELECT "user_name" FROM "users" WHERE "deleted_at" IS NULL` {
+		t.Errorf("got %s", query)
+	}
+	if _, _, err := aliased.projectSQL(nil, projection{columns: []string{"password"}}, nil, nil, nil); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestRepository_Pluck(t *testing.T) {
+	t.Parallel()
+	conn := &testConn{queries: []testQueryResult{
+		{columns: []string{"id"}, rows: [][]sqlDriver.Value{{"a"}, {[]byte("b")}}},
+	}}
+	repo := newSimpleTestRepo(t, conn, simpleTable)
+	ids, err := Pluck[string, string](repo.Query(context.Background()), "id")
+	if err != nil || !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("got %v, %v", ids, err)
+	}
+}
+
+func TestMemory_SelectAndPluck(t *testing.T) {
+	t.Parallel()
+	m := newMemUsers(t,
+		memUser{ID: 1, Name: "a", Age: 30},
+		memUser{ID: 2, Name: "b", Age: 20},
+		memUser{ID: 3, Name: "c", Age: 30},
+		memUser{ID: 4, Name: "d", Age: 10},
+	)
+	ctx := context.Background()
+
+	type nameAge struct {
+		Name string
+		Age  int
+	}
+	rows, err := Select(m.Query(ctx).Where(Gt("age", 10)).OrderBy("name", Desc).Limit(2),
+		[]string{"name", "age"}, func(sc Scanner) (nameAge, error) {
+			var r nameAge
+			return r, sc.Scan(&r.Name, &r.Age)
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(rows, []nameAge{{"c", 30}, {"b", 20}}) {
+		t.Errorf("got %v", rows)
+	}
+
+	ages, err := Pluck[memUser, int](m.Query(ctx).Distinct().OrderBy("age", Asc).Offset(1), "age")
+	if err != nil || !reflect.DeepEqual(ages, []int{20, 30}) {
+		t.Errorf("got %v, %v", ages, err)
+	}
+	if _, err := Pluck[memUser, int](m.Query(ctx).Distinct().OrderBy("name", Asc), "age"); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("ORDER BY outside the DISTINCT list: expected ErrInvalidSpec, got %v", err)
+	}
+	distinct := m.Query(ctx).Distinct()
+	if _, err := distinct.All(); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("All with Distinct: expected ErrInvalidSpec, got %v", err)
+	}
+	if _, err := distinct.Count(); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("Count with Distinct: expected ErrInvalidSpec, got %v", err)
+	}
+	if _, err := Pluck[memUser, int](m.Query(ctx), "missing"); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+	if _, err := Select(m.Query(ctx), nil, func(Scanner) (int, error) { return 0, nil }); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec, got %v", err)
+	}
+}
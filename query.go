@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -13,13 +14,41 @@ const (
 	Desc Direction = "DESC"
 )
 
+type Nulls string
+
+const (
+	NullsFirst Nulls = "FIRST"
+	NullsLast  Nulls = "LAST"
+)
+
 type orderClause struct {
-	column string
-	dir    Direction
+	column     string
+	expr       Expression
+	rank       *textSearchSpec
+	dir        Direction
+	nulls      Nulls
+	nullsFirst bool
+	nullable   bool
+}
+
+type querySource[T any] interface {
+	queryDialect() Dialect
+	primaryKey() []string
+	nullable(column string) bool
+	prepareSpec(s Spec) (Spec, error)
+	prepareOrders(orders []orderClause) ([]orderClause, error)
+	selectItems(ctx context.Context, s Spec, orders []orderClause, limit, offset *int64) ([]T, error)
+	selectCounted(ctx context.Context, s Spec, orders []orderClause, limit, offset *int64) ([]T, int64, error)
+	cursorValues(item T, orders []orderClause) (map[string]any, error)
+	cursorKey() []byte
+	aggregate(ctx context.Context, s Spec, a aggregateQuery) ([][]any, error)
+	project(ctx context.Context, s Spec, p projection, orders []orderClause, limit, offset *int64) ([][]any, error)
+	count(ctx context.Context, s Spec) (int64, error)
+	exists(ctx context.Context, s Spec) (bool, error)
 }
 
 type Query[T any] struct {
-	repo      *Repository[T]
+	src       querySource[T]
 	ctx       context.Context
 	specs     []Spec
 	orderCols []orderClause
@@ -28,45 +57,98 @@ type Query[T any] struct {
 	pageSize  *int64
 	cursor    string
 	forward   bool
+	distinct  bool
+}
+
+func (q *Query[T]) Clone() *Query[T] {
+	c := *q
+	c.specs = slices.Clone(q.specs)
+	c.orderCols = slices.Clone(q.orderCols)
+	return &c
+}
+
+func (q *Query[T]) WithContext(ctx context.Context) *Query[T] {
+	c := q.Clone()
+	c.ctx = ctx
+	return c
 }
 
 func (q *Query[T]) Where(s Spec) *Query[T] {
-	q.specs = append(q.specs, s)
-	return q
+	c := q.Clone()
+	if s != nil {
+		c.specs = append(c.specs, s)
+	}
+	return c
+}
+
+func (q *Query[T]) OrderBy(column string, dir Direction, nulls ...Nulls) *Query[T] {
+	return q.withOrder(orderClause{column: column, dir: dir, nulls: firstNulls(nulls)})
+}
+
+func (q *Query[T]) OrderByExpr(e Expression, dir Direction, nulls ...Nulls) *Query[T] {
+	if c, ok := e.(colExpr); ok {
+		return q.OrderBy(c.name, dir, nulls...)
+	}
+	return q.withOrder(orderClause{expr: e, dir: dir, nulls: firstNulls(nulls)})
+}
+
+func firstNulls(nulls []Nulls) Nulls {
+	if len(nulls) == 0 {
+		return ""
+	}
+	return nulls[0]
+}
+
+func (q *Query[T]) OrderByRank(columns []string, query string) *Query[T] {
+	return q.withOrder(orderClause{rank: &textSearchSpec{columns: columns, query: query}, dir: Desc})
+}
+
+func (q *Query[T]) Sort(s SortSpec) *Query[T] {
+	orders := make([]orderClause, len(s))
+	for i, f := range s {
+		orders[i] = orderClause{column: f.Column, dir: f.Dir}
+	}
+	return q.withOrder(orders...)
 }
 
-func (q *Query[T]) OrderBy(column string, dir Direction) *Query[T] {
-	q.orderCols = append(q.orderCols, orderClause{column: column, dir: dir})
-	return q
+func (q *Query[T]) withOrder(orders ...orderClause) *Query[T] {
+	c := q.Clone()
+	c.orderCols = append(c.orderCols, orders...)
+	return c
 }
 
-func (q *Query[T]) Limit(n int64) *Query[T]    { q.limit = &n; return q }
-func (q *Query[T]) Offset(n int64) *Query[T]   { q.offset = &n; return q }
-func (q *Query[T]) PageSize(n int64) *Query[T] { q.pageSize = &n; return q }
+func (q *Query[T]) Limit(n int64) *Query[T]    { c := q.Clone(); c.limit = &n; return c }
+func (q *Query[T]) Offset(n int64) *Query[T]   { c := q.Clone(); c.offset = &n; return c }
+func (q *Query[T]) PageSize(n int64) *Query[T] { c := q.Clone(); c.pageSize = &n; return c }
 
 func (q *Query[T]) After(cursor string) *Query[T] {
-	q.cursor = cursor
-	q.forward = true
-	return q
+	c := q.Clone()
+	c.cursor = cursor
+	c.forward = true
+	return c
 }
 
 func (q *Query[T]) Before(cursor string) *Query[T] {
-	q.cursor = cursor
-	q.forward = false
-	return q
+	c := q.Clone()
+	c.cursor = cursor
+	c.forward = false
+	return c
 }
 
-func (q *Query[T]) All() ([]T, error) {
-	query, args := q.buildSQL()
-	return q.repo.driver.findMany(q.ctx, q.repo.exec(), query, args)
+func (q *Query[T]) All() ([]T, error) { return q.AllContext(q.context()) }
+
+func (q *Query[T]) AllContext(ctx context.Context) ([]T, error) {
+	spec, orders, err := q.prepare()
+	if err != nil {
+		return nil, err
+	}
+	return q.src.selectItems(ctx, spec, orders, q.limit, q.offset)
 }
 
-func (q *Query[T]) First() (T, error) {
-	one := int64(1)
-	q.limit = &one
-	query, args := q.buildSQL()
+func (q *Query[T]) First() (T, error) { return q.FirstContext(q.context()) }
 
-	items, err := q.repo.driver.findMany(q.ctx, q.repo.exec(), query, args)
+func (q *Query[T]) FirstContext(ctx context.Context) (T, error) {
+	items, err := q.Limit(1).AllContext(ctx)
 	if err != nil {
 		var zero T
 		return zero, err
@@ -78,66 +160,62 @@ func (q *Query[T]) First() (T, error) {
 	return items[0], nil
 }
 
-func (q *Query[T]) Count() (int64, error) {
-	spec := q.combinedSpec()
-	spec = q.repo.withSoftDelete(spec)
+func (q *Query[T]) Count() (int64, error) { return q.CountContext(q.context()) }
 
-	d := q.repo.dialect
-	var query string
-	var args []any
-
-	if spec != nil {
-		condition, a, _ := spec.ToSQL(d, 1)
-		args = a
-		query = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", q.repo.table.Name, condition)
-	} else {
-		query = fmt.Sprintf("SELECT COUNT(*) FROM %s", q.repo.table.Name)
+func (q *Query[T]) CountContext(ctx context.Context) (int64, error) {
+	spec, _, err := q.prepare()
+	if err != nil {
+		return 0, err
 	}
-
-	exec := q.repo.exec()
-	var count int64
-	err := exec.QueryRowContext(q.ctx, query, args...).Scan(&count)
-	return count, err
+	return q.src.count(ctx, spec)
 }
 
-func (q *Query[T]) Exists() (bool, error) {
-	spec := q.combinedSpec()
-	spec = q.repo.withSoftDelete(spec)
+func (q *Query[T]) Exists() (bool, error) { return q.ExistsContext(q.context()) }
 
-	d := q.repo.dialect
-	var query string
-	var args []any
+func (q *Query[T]) ExistsContext(ctx context.Context) (bool, error) {
+	spec, _, err := q.prepare()
+	if err != nil {
+		return false, err
+	}
+	return q.src.exists(ctx, spec)
+}
 
-	if spec != nil {
-		condition, a, _ := spec.ToSQL(d, 1)
-		args = a
-		query = fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s)", q.repo.table.Name, condition)
-	} else {
-		query = fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s)", q.repo.table.Name)
+func (q *Query[T]) context() context.Context {
+	if q.ctx == nil {
+		return context.Background()
 	}
+	return q.ctx
+}
 
-	exec := q.repo.exec()
-	var exists bool
-	err := exec.QueryRowContext(q.ctx, query, args...).Scan(&exists)
-	return exists, err
+func (q *Query[T]) Page(extract ...CursorExtractor[T]) (*Page[T], error) {
+	return q.PageContext(q.context(), extract...)
 }
 
-func (q *Query[T]) Page(extract CursorExtractor[T]) (*Page[T], error) {
-	if q.pageSize == nil {
-		size := int64(20)
-		q.pageSize = &size
+func (q *Query[T]) PageContext(ctx context.Context, extract ...CursorExtractor[T]) (*Page[T], error) {
+	size := int64(20)
+	if q.pageSize != nil {
+		size = *q.pageSize
 	}
 
-	d := q.repo.dialect
-	orders := q.ensurePKOrder()
-	spec := q.combinedSpec()
-	spec = q.repo.withSoftDelete(spec)
+	spec, orders, err := q.prepare()
+	if err != nil {
+		return nil, err
+	}
+	orders = q.ensurePKOrder(orders)
+	fingerprint := cursorFingerprint(q.src.queryDialect(), spec, orders)
+	if hasExprOrder(orders) {
+		return q.offsetPage(ctx, spec, orders, size, fingerprint)
+	}
 
+	backward := q.cursor != "" && !q.forward
 	if q.cursor != "" {
-		cur, err := DecodeCursor(q.cursor)
+		cur, err := q.decodeCursor(fingerprint)
 		if err != nil {
 			return nil, err
 		}
+		if err := checkCursorValues(orders, cur.Values); err != nil {
+			return nil, err
+		}
 		keysetSpec := buildKeysetSpec(orders, cur.Values, q.forward)
 		if keysetSpec != nil {
 			if spec != nil {
@@ -147,42 +225,210 @@ func (q *Query[T]) Page(extract CursorExtractor[T]) (*Page[T], error) {
 			}
 		}
 	}
+	if backward {
+		orders = reverseOrders(orders)
+	}
 
-	fetchSize := *q.pageSize + 1
-	var query string
-	var args []any
-	nextParam := 1
+	fetchSize := size + 1
+	items, err := q.src.selectItems(ctx, spec, orders, &fetchSize, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	more := int64(len(items)) > size
+	if more {
+		items = items[:len(items)-1]
+	}
+	page := &Page[T]{Items: items, HasMore: more, HasPrev: q.cursor != ""}
+	if backward {
+		slices.Reverse(items)
+		page.HasMore, page.HasPrev = true, more
+	}
 
-	if spec != nil {
-		condition, specArgs, np := spec.ToSQL(d, 1)
-		args = specArgs
-		nextParam = np
-		query = q.repo.table.selectWhere(condition)
-	} else {
-		query = q.repo.table.selectFrom()
+	if len(items) == 0 {
+		return page, nil
+	}
+	cursorOf := func(item T) (string, error) {
+		if len(extract) > 0 && extract[0] != nil {
+			return q.encodeCursor(Cursor{Values: extract[0](item), Fingerprint: fingerprint}), nil
+		}
+		values, err := q.src.cursorValues(item, orders)
+		return q.encodeCursor(Cursor{Values: values, Fingerprint: fingerprint}), err
+	}
+	if page.HasMore {
+		if page.NextCursor, err = cursorOf(items[len(items)-1]); err != nil {
+			return nil, err
+		}
+	}
+	if page.HasPrev {
+		if page.PrevCursor, err = cursorOf(items[0]); err != nil {
+			return nil, err
+		}
 	}
+	return page, nil
+}
 
-	query += buildOrderSQL(orders)
-	query += fmt.Sprintf(" LIMIT %s", d.Placeholder(nextParam))
-	args = append(args, fetchSize)
+type OffsetPage[T any] struct {
+	Items      []T   `json:"items"`
+	Total      int64 `json:"total"`
+	Page       int64 `json:"page"`
+	PerPage    int64 `json:"per_page"`
+	TotalPages int64 `json:"total_pages"`
+}
 
-	items, err := q.repo.driver.findMany(q.ctx, q.repo.exec(), query, args)
+func (q *Query[T]) Paginate(page, perPage int64) (*OffsetPage[T], error) {
+	return q.PaginateContext(q.context(), page, perPage)
+}
+
+func (q *Query[T]) PaginateContext(ctx context.Context, page, perPage int64) (*OffsetPage[T], error) {
+	spec, orders, err := q.preparePage(page, perPage)
 	if err != nil {
 		return nil, err
 	}
+	total, err := q.src.count(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	result := newOffsetPage[T](total, page, perPage)
+	offset := (page - 1) * perPage
+	if offset >= total {
+		return result, nil
+	}
+	result.Items, err = q.src.selectItems(ctx, spec, orders, &perPage, &offset)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (q *Query[T]) PaginateWindow(page, perPage int64) (*OffsetPage[T], error) {
+	return q.PaginateWindowContext(q.context(), page, perPage)
+}
 
-	hasMore := int64(len(items)) > *q.pageSize
+func (q *Query[T]) PaginateWindowContext(ctx context.Context, page, perPage int64) (*OffsetPage[T], error) {
+	spec, orders, err := q.preparePage(page, perPage)
+	if err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * perPage
+	items, total, err := q.src.selectCounted(ctx, spec, orders, &perPage, &offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 && offset > 0 {
+		if total, err = q.src.count(ctx, spec); err != nil {
+			return nil, err
+		}
+	}
+	result := newOffsetPage[T](total, page, perPage)
+	result.Items = items
+	return result, nil
+}
+
+func (q *Query[T]) preparePage(page, perPage int64) (Spec, []orderClause, error) {
+	if page < 1 || perPage < 1 {
+		return nil, nil, fmt.Errorf("%w: page %d, per page %d", ErrInvalidPage, page, perPage)
+	}
+	if q.limit != nil || q.offset != nil {
+		return nil, nil, fmt.Errorf("%w: Paginate cannot be combined with Limit or Offset", ErrInvalidPage)
+	}
+	spec, orders, err := q.prepare()
+	if err != nil {
+		return nil, nil, err
+	}
+	return spec, q.ensurePKOrder(orders), nil
+}
+
+func newOffsetPage[T any](total, page, perPage int64) *OffsetPage[T] {
+	return &OffsetPage[T]{
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: (total + perPage - 1) / perPage,
+	}
+}
+
+func reverseOrders(orders []orderClause) []orderClause {
+	reversed := make([]orderClause, len(orders))
+	for i, o := range orders {
+		o.dir = Desc
+		if orders[i].dir == Desc {
+			o.dir = Asc
+		}
+		switch o.nulls {
+		case NullsFirst:
+			o.nulls = NullsLast
+		case NullsLast:
+			o.nulls = NullsFirst
+		}
+		o.nullsFirst = !o.nullsFirst
+		reversed[i] = o
+	}
+	return reversed
+}
+
+func (q *Query[T]) offsetPage(
+	ctx context.Context, spec Spec, orders []orderClause, size int64, fingerprint string,
+) (*Page[T], error) {
+	start := int64(0)
+	if q.cursor != "" {
+		cur, err := q.decodeCursor(fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		start = cur.Offset
+		if !q.forward {
+			start = max(0, cur.Offset-size)
+			size = cur.Offset - start
+		}
+	}
+
+	fetchSize := size + 1
+	items, err := q.src.selectItems(ctx, spec, orders, &fetchSize, &start)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := int64(len(items)) > size
 	if hasMore {
 		items = items[:len(items)-1]
 	}
 
-	var nextCursor string
-	if hasMore && len(items) > 0 {
-		last := items[len(items)-1]
-		nextCursor = EncodeCursor(Cursor{Values: extract(last)})
+	page := &Page[T]{Items: items, HasMore: hasMore, HasPrev: start > 0}
+	if hasMore {
+		page.NextCursor = q.encodeCursor(Cursor{Offset: start + int64(len(items)), Fingerprint: fingerprint})
 	}
+	if page.HasPrev {
+		page.PrevCursor = q.encodeCursor(Cursor{Offset: start, Fingerprint: fingerprint})
+	}
+	return page, nil
+}
 
-	return &Page[T]{Items: items, NextCursor: nextCursor, HasMore: hasMore}, nil
+func (q *Query[T]) encodeCursor(c Cursor) string {
+	return SignCursor(c, q.src.cursorKey())
+}
+
+func (q *Query[T]) decodeCursor(fingerprint string) (Cursor, error) {
+	cur, err := VerifyCursor(q.cursor, q.src.cursorKey())
+	if err != nil {
+		return Cursor{}, err
+	}
+	if cur.Fingerprint == "" && len(q.src.cursorKey()) == 0 {
+		return cur, nil
+	}
+	if cur.Fingerprint != fingerprint {
+		return Cursor{}, fmt.Errorf("%w: issued for a different filter or order", ErrInvalidCursor)
+	}
+	return cur, nil
+}
+
+func hasExprOrder(orders []orderClause) bool {
+	for _, o := range orders {
+		if o.expr != nil || o.rank != nil {
+			return true
+		}
+	}
+	return false
 }
 
 func (q *Query[T]) combinedSpec() Spec {
@@ -195,16 +441,37 @@ func (q *Query[T]) combinedSpec() Spec {
 	return And(q.specs...)
 }
 
-func (q *Query[T]) ensurePKOrder() []orderClause {
-	orders := make([]orderClause, len(q.orderCols))
-	copy(orders, q.orderCols)
+func (q *Query[T]) prepare() (Spec, []orderClause, error) {
+	if q.distinct {
+		return nil, nil, fmt.Errorf("%w: Distinct applies only to Select and Pluck", ErrInvalidSpec)
+	}
+	return q.prepareQuery()
+}
 
+func (q *Query[T]) prepareQuery() (Spec, []orderClause, error) {
+	spec, err := q.src.prepareSpec(q.combinedSpec())
+	if err != nil {
+		return nil, nil, err
+	}
+	orders, err := q.src.prepareOrders(q.orderCols)
+	if err != nil {
+		return nil, nil, err
+	}
+	return spec, orders, nil
+}
+
+func (q *Query[T]) ensurePKOrder(base []orderClause) []orderClause {
+	orders := make([]orderClause, len(base))
+	copy(orders, base)
+
+	pks := q.src.primaryKey()
 	existing := make(map[string]bool, len(orders))
-	for _, o := range orders {
+	for i, o := range orders {
 		existing[o.column] = true
+		orders[i].nullable = !slices.Contains(pks, o.column) && (o.nulls != "" || q.src.nullable(o.column))
 	}
 
-	for _, pk := range q.repo.table.PrimaryKey {
+	for _, pk := range pks {
 		if !existing[pk] {
 			orders = append(orders, orderClause{column: pk, dir: Asc})
 		}
@@ -212,48 +479,27 @@ func (q *Query[T]) ensurePKOrder() []orderClause {
 	return orders
 }
 
-func (q *Query[T]) buildSQL() (string, []any) {
-	d := q.repo.dialect
-	spec := q.combinedSpec()
-	spec = q.repo.withSoftDelete(spec)
-
-	var query string
-	var args []any
-	nextParam := 1
-
-	if spec != nil {
-		condition, specArgs, np := spec.ToSQL(d, 1)
-		args = specArgs
-		nextParam = np
-		query = q.repo.table.selectWhere(condition)
-	} else {
-		query = q.repo.table.selectFrom()
-	}
-
-	if len(q.orderCols) > 0 {
-		query += buildOrderSQL(q.orderCols)
-	}
-
-	if q.limit != nil {
-		query += fmt.Sprintf(" LIMIT %s", d.Placeholder(nextParam))
-		args = append(args, *q.limit)
-		nextParam++
-	}
-	if q.offset != nil {
-		query += fmt.Sprintf(" OFFSET %s", d.Placeholder(nextParam))
-		args = append(args, *q.offset)
-	}
-
-	return query, args
+func buildOrderSQL(d Dialect, orders []orderClause) string {
+	sql, _, _ := renderOrderSQL(d, orders, 1)
+	return sql
 }
 
-func buildOrderSQL(orders []orderClause) string {
+func renderOrderSQL(d Dialect, orders []orderClause, offset int) (string, []any, int) {
 	if len(orders) == 0 {
-		return ""
+		return "", nil, offset
 	}
+	var args []any
+	bind := binder(d, offset, &args)
 	parts := make([]string, len(orders))
 	for i, o := range orders {
-		parts[i] = o.column + " " + string(o.dir)
+		switch {
+		case o.rank != nil:
+			parts[i] = orderSQL(d, o.rank.rankSQL(d, bind), string(o.dir), string(o.nulls))
+		case o.expr != nil:
+			parts[i] = orderSQL(d, o.expr.ExprSQL(d), string(o.dir), string(o.nulls))
+		default:
+			parts[i] = orderSQL(d, quoteIdent(d, o.column), string(o.dir), string(o.nulls))
+		}
 	}
-	return " ORDER BY " + strings.Join(parts, ", ")
+	return " ORDER BY " + strings.Join(parts, ", "), args, offset + len(args)
 }
@@ -5,12 +5,13 @@ import (
 	sqlDriver "database/sql/driver"
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
 func TestBuildOrderSQL_Empty(t *testing.T) {
 	t.Parallel()
-	if sql := buildOrderSQL(nil); sql != "" {
+	if sql := buildOrderSQL(pgDialect(), nil); sql != "" {
 		t.Errorf("expected empty, got %q", sql)
 	}
 }
@@ -18,7 +19,7 @@ func TestBuildOrderSQL_Empty(t *testing.T) {
 func TestBuildOrderSQL_Single(t *testing.T) {
 	t.Parallel()
 	orders := []orderClause{{column: "name", dir: Asc}}
-	if sql := buildOrderSQL(orders); sql != " ORDER BY name ASC" {
+	if sql := buildOrderSQL(pgDialect(), orders); sql != ` ORDER BY "name" ASC` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -26,7 +27,7 @@ func TestBuildOrderSQL_Single(t *testing.T) {
 func TestBuildOrderSQL_Multiple(t *testing.T) {
 	t.Parallel()
 	orders := []orderClause{{column: "name", dir: Asc}, {column: "id", dir: Desc}}
-	if sql := buildOrderSQL(orders); sql != " ORDER BY name ASC, id DESC" {
+	if sql := buildOrderSQL(pgDialect(), orders); sql != ` ORDER BY "name" ASC, "id" DESC` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -59,8 +60,8 @@ func TestQuery_CombinedSpec_Multiple(t *testing.T) {
 func TestQuery_EnsurePKOrder_AlreadyPresent(t *testing.T) {
 	t.Parallel()
 	r := &Repository[string]{table: Table{PrimaryKey: []string{"id"}}}
-	q := &Query[string]{repo: r, orderCols: []orderClause{{column: "id", dir: Desc}}}
-	if len(q.ensurePKOrder()) != 1 {
+	q := &Query[string]{src: r, orderCols: []orderClause{{column: "id", dir: Desc}}}
+	if len(q.ensurePKOrder(q.orderCols)) != 1 {
 		t.Error("should not add duplicate PK")
 	}
 }
@@ -68,38 +69,100 @@ func TestQuery_EnsurePKOrder_AlreadyPresent(t *testing.T) {
 func TestQuery_EnsurePKOrder_NotPresent(t *testing.T) {
 	t.Parallel()
 	r := &Repository[string]{table: Table{PrimaryKey: []string{"id"}}}
-	q := &Query[string]{repo: r, orderCols: []orderClause{{column: "name", dir: Asc}}}
-	orders := q.ensurePKOrder()
+	q := &Query[string]{src: r, orderCols: []orderClause{{column: "name", dir: Asc}}}
+	orders := q.ensurePKOrder(q.orderCols)
 	if len(orders) != 2 || orders[1].column != "id" {
 		t.Error("expected PK appended")
 	}
 }
 
+func TestQuery_EnsurePKOrder_Nullable(t *testing.T) {
+	t.Parallel()
+	r := &Repository[string]{table: Table{PrimaryKey: []string{"id"}, Nullable: []string{"due"}}}
+	q := &Query[string]{src: r, orderCols: []orderClause{
+		{column: "name", dir: Asc}, {column: "due", dir: Asc}, {column: "score", dir: Asc, nulls: NullsLast},
+	}}
+	got := q.ensurePKOrder(q.orderCols)
+	for i, want := range []bool{false, true, true, false} {
+		if got[i].nullable != want {
+			t.Errorf("%s: expected nullable %v", got[i].column, want)
+		}
+	}
+	sql, _, _ := keysetAfter(got[0], "bob", true).ToSQL(Postgres(), 1)
+	if sql != `"name" > $1` {
+		t.Errorf("non-null column must use a plain comparison, got %s", sql)
+	}
+}
+
 func TestQuery_Chainable(t *testing.T) {
 	t.Parallel()
-	q := &Query[string]{}
-	q.Where(Eq("a", 1)).OrderBy("x", Desc).Limit(10).Offset(5).PageSize(20)
+	base := &Query[string]{}
+	q := base.Where(Eq("a", 1)).OrderBy("x", Desc).Limit(10).Offset(5).PageSize(20)
 	if len(q.specs) != 1 || len(q.orderCols) != 1 {
 		t.Error("chain failed")
 	}
 	if q.limit == nil || *q.limit != 10 || q.offset == nil || *q.offset != 5 {
 		t.Error("limit/offset not set")
 	}
+	if len(base.specs) != 0 || len(base.orderCols) != 0 || base.limit != nil || base.offset != nil {
+		t.Errorf("builder methods must not modify the receiver: %+v", base)
+	}
 }
 
 func TestQuery_AfterBefore(t *testing.T) {
 	t.Parallel()
 	q := &Query[string]{}
-	q.After("abc")
-	if q.cursor != "abc" || !q.forward {
+	after := q.After("abc")
+	if after.cursor != "abc" || !after.forward || q.cursor != "" {
 		t.Error("After failed")
 	}
-	q.Before("def")
-	if q.cursor != "def" || q.forward {
+	before := after.Before("def")
+	if before.cursor != "def" || before.forward || after.cursor != "abc" || !after.forward {
 		t.Error("Before failed")
 	}
 }
 
+func TestQuery_CopyOnWrite(t *testing.T) {
+	t.Parallel()
+	m := newMemUsers(t, memUser{ID: 1, Name: "a", Age: 30}, memUser{ID: 2, Name: "b", Age: 20},
+		memUser{ID: 3, Name: "c", Age: 30})
+	base := m.NewQuery().Where(Eq("age", 30))
+
+	adults := base.OrderBy("name", Desc)
+	young := base.Where(Lt("id", 2))
+	if len(base.specs) != 1 || len(base.orderCols) != 0 || len(young.orderCols) != 0 {
+		t.Fatalf("derived queries must not share state: base %+v, young %+v", base, young)
+	}
+
+	first, err := adults.First()
+	if err != nil || first.ID != 3 {
+		t.Errorf("unexpected first: %+v, %v", first, err)
+	}
+	if adults.limit != nil {
+		t.Error("First must not set a limit on the query")
+	}
+	all, err := adults.AllContext(context.Background())
+	if err != nil || len(all) != 2 {
+		t.Errorf("expected both rows after First, got %v, %v", all, err)
+	}
+	if n, err := young.CountContext(context.Background()); err != nil || n != 1 {
+		t.Errorf("unexpected count: %d, %v", n, err)
+	}
+
+	clone := base.Clone()
+	clone.specs[0] = Eq("age", 20)
+	if n, _ := base.Count(); n != 2 {
+		t.Errorf("Clone must not share specs with the original, got %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bound := base.WithContext(ctx)
+	if bound.ctx != ctx || base.ctx != nil {
+		t.Error("WithContext must return a copy bound to ctx")
+	}
+}
+
 func TestQuery_All_Success(t *testing.T) {
 	t.Parallel()
 	conn := &testConn{queries: []testQueryResult{
@@ -237,15 +300,21 @@ func TestQuery_Page_DefaultSize(t *testing.T) {
 	}
 }
 
+func pageCursor[T any](q *Query[T], c Cursor) string {
+	spec, orders, _ := q.prepare()
+	c.Fingerprint = cursorFingerprint(q.src.queryDialect(), spec, q.ensurePKOrder(orders))
+	return q.encodeCursor(c)
+}
+
 func TestQuery_Page_WithCursor(t *testing.T) {
 	t.Parallel()
-	cursor := EncodeCursor(Cursor{Values: map[string]any{"id": "last"}})
 	conn := &testConn{queries: []testQueryResult{
 		{columns: []string{"id"}, rows: [][]sqlDriver.Value{{"next1"}, {"next2"}}},
 	}}
 	repo := newSimpleTestRepo(t, conn, simpleTable)
 	ext := func(s string) map[string]any { return map[string]any{"id": s} }
-	page, err := repo.Query(context.Background()).PageSize(5).After(cursor).Page(ext)
+	q := repo.Query(context.Background()).PageSize(5)
+	page, err := q.After(pageCursor(q, Cursor{Values: map[string]any{"id": "last"}})).Page(ext)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -284,12 +353,24 @@ func TestQuery_Page_NoItems(t *testing.T) {
 	}
 }
 
+func buildQuerySQL[T any](q *Query[T]) (string, []any, error) {
+	spec, orders, err := q.prepare()
+	if err != nil {
+		return "", nil, err
+	}
+	query, args := q.src.(*Repository[T]).selectSQL(spec, orders, q.limit, q.offset)
+	return query, args, nil
+}
+
 func TestQuery_BuildSQL_NoSpecNoOrder(t *testing.T) {
 	t.Parallel()
 	r := &Repository[string]{table: simpleTable, dialect: Postgres()}
-	q := &Query[string]{repo: r, forward: true}
-	sql, args := q.buildSQL()
-	if sql != "SELECT id FROM items" || len(args) != 0 {
+	q := &Query[string]{src: r, forward: true}
+	sql, args, err := buildQuerySQL(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql != `SELECT "id" FROM "items"` || len(args) != 0 {
 		t.Errorf("got %q, args=%v", sql, args)
 	}
 }
@@ -299,11 +380,14 @@ func TestQuery_BuildSQL_WithSpecOrderLimitOffset(t *testing.T) {
 	r := &Repository[string]{table: simpleTable, dialect: Postgres()}
 	lim, off := int64(10), int64(5)
 	q := &Query[string]{
-		repo: r, specs: []Spec{Eq("id", "x")},
+		src: r, specs: []Spec{Eq("id", "x")},
 		orderCols: []orderClause{{column: "id", dir: Asc}},
 		limit:     &lim, offset: &off, forward: true,
 	}
-	_, args := q.buildSQL()
+	_, args, err := buildQuerySQL(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	if len(args) != 3 {
 		t.Errorf("expected 3 args, got %d", len(args))
 	}
@@ -328,13 +412,13 @@ func TestQuery_Page_WithSoftDelete(t *testing.T) {
 
 func TestQuery_Page_CursorWithNoExtraSpec(t *testing.T) {
 	t.Parallel()
-	cursor := EncodeCursor(Cursor{Values: map[string]any{"id": "z"}})
 	conn := &testConn{queries: []testQueryResult{
 		{columns: []string{"id"}, rows: [][]sqlDriver.Value{{"a"}}},
 	}}
 	repo := newSimpleTestRepo(t, conn, simpleTable)
 	ext := func(s string) map[string]any { return map[string]any{"id": s} }
-	page, err := repo.Query(context.Background()).PageSize(5).After(cursor).Page(ext)
+	q := repo.Query(context.Background()).PageSize(5)
+	page, err := q.After(pageCursor(q, Cursor{Values: map[string]any{"id": "z"}})).Page(ext)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -342,3 +426,221 @@ func TestQuery_Page_CursorWithNoExtraSpec(t *testing.T) {
 		t.Errorf("expected 1, got %d", len(page.Items))
 	}
 }
+
+func TestBuildOrderSQL_Nulls(t *testing.T) {
+	t.Parallel()
+	orders := []orderClause{{column: "due", dir: Asc, nulls: NullsFirst}, {column: "id", dir: Desc, nulls: NullsLast}}
+	tests := []struct {
+		d    Dialect
+		want string
+	}{
+		{Postgres(), ` ORDER BY "due" ASC NULLS FIRST, "id" DESC NULLS LAST`},
+		{MySQL(), " ORDER BY `due` ASC, `id` DESC"},
+		{SQLite(), ` ORDER BY "due" ASC, "id" DESC`},
+	}
+	for _, tt := range tests {
+		if got := buildOrderSQL(tt.d, orders); got != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, got)
+		}
+	}
+	flipped := []orderClause{{column: "due", dir: Asc, nulls: NullsLast}, {column: "id", dir: Desc, nulls: NullsFirst}}
+	want := ` ORDER BY "due" IS NULL, "due" ASC, "id" IS NULL DESC, "id" DESC`
+	if got := buildOrderSQL(SQLite(), flipped); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	r := &Repository[string]{table: simpleTable, dialect: Postgres()}
+	if _, err := r.Query(context.Background()).OrderBy("id", Asc, "SOMETIMES").All(); !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("expected ErrInvalidDirection, got %v", err)
+	}
+}
+
+type nullableTask struct {
+	ID  int
+	Due *string
+}
+
+func TestMemory_Page_NullableOrderVisitsEveryRow(t *testing.T) {
+	t.Parallel()
+	m := NewMemory(Simple(SimpleConfig[nullableTask]{
+		Table: Table{Name: "tasks", PrimaryKey: []string{"id"}, Columns: []string{"id", "due"}},
+		Scan: func(sc Scanner) (nullableTask, error) {
+			var task nullableTask
+			err := sc.Scan(&task.ID, &task.Due)
+			return task, err
+		},
+		Values: func(task nullableTask) []any { return []any{task.ID, task.Due} },
+	}))
+	ctx := context.Background()
+	day := func(s string) *string { return &s }
+	for i, due := range []*string{nil, day("b"), nil, day("a"), day("b"), nil} {
+		if err := m.Save(ctx, nullableTask{ID: i + 1, Due: due}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	extract := func(task nullableTask) map[string]any {
+		var due any
+		if task.Due != nil {
+			due = *task.Due
+		}
+		return map[string]any{"due": due, "id": task.ID}
+	}
+	for _, nulls := range []Nulls{NullsFirst, NullsLast} {
+		for _, dir := range []Direction{Asc, Desc} {
+			var ids []int
+			cursor := ""
+			for range 10 {
+				q := m.Query(ctx).OrderBy("due", dir, nulls).PageSize(2)
+				if cursor != "" {
+					q = q.After(cursor)
+				}
+				page, err := q.Page(extract)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				for _, task := range page.Items {
+					ids = append(ids, task.ID)
+				}
+				if !page.HasMore {
+					break
+				}
+				cursor = page.NextCursor
+			}
+			all, _ := m.Query(ctx).OrderBy("due", dir, nulls).All()
+			var want []int
+			for _, task := range all {
+				want = append(want, task.ID)
+			}
+			if !reflect.DeepEqual(ids, want) || len(ids) != 6 {
+				t.Errorf("%s %s: paged %v, expected %v", dir, nulls, ids, want)
+			}
+			if (nulls == NullsFirst) != (all[0].Due == nil) {
+				t.Errorf("%s %s: wrong null placement %v", dir, nulls, want)
+			}
+		}
+	}
+}
+
+func TestMemory_Page_BackwardNavigation(t *testing.T) {
+	t.Parallel()
+	var users []memUser
+	for i := 1; i <= 7; i++ {
+		users = append(users, memUser{ID: i, Name: fmt.Sprintf("u%d", i), Age: i % 3})
+	}
+	m := newMemUsers(t, users...)
+	ctx := context.Background()
+	extract := func(u memUser) map[string]any { return map[string]any{"age": u.Age, "id": u.ID} }
+	ids := func(p *Page[memUser]) []int {
+		var out []int
+		for _, u := range p.Items {
+			out = append(out, u.ID)
+		}
+		return out
+	}
+	query := func() *Query[memUser] { return m.Query(ctx).OrderBy("age", Desc).PageSize(3) }
+
+	first, err := query().Page(extract)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids(first), []int{2, 5, 1}) || first.HasPrev || first.PrevCursor != "" || !first.HasMore {
+		t.Fatalf("unexpected first page: %v %+v", ids(first), first)
+	}
+	second, _ := query().After(first.NextCursor).Page(extract)
+	if !reflect.DeepEqual(ids(second), []int{4, 7, 3}) || !second.HasPrev || !second.HasMore {
+		t.Fatalf("unexpected second page: %v %+v", ids(second), second)
+	}
+	third, _ := query().After(second.NextCursor).Page(extract)
+	if !reflect.DeepEqual(ids(third), []int{6}) || third.HasMore || third.NextCursor != "" {
+		t.Fatalf("unexpected third page: %v %+v", ids(third), third)
+	}
+
+	back, err := query().Before(third.PrevCursor).Page(extract)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids(back), ids(second)) || !back.HasPrev || !back.HasMore {
+		t.Errorf("expected second page again, got %v %+v", ids(back), back)
+	}
+	start, _ := query().Before(back.PrevCursor).Page(extract)
+	if !reflect.DeepEqual(ids(start), ids(first)) || start.HasPrev || start.PrevCursor != "" || start.NextCursor == "" {
+		t.Errorf("expected first page again, got %v %+v", ids(start), start)
+	}
+}
+
+func TestQuery_Page_BackwardReversesOrderSQL(t *testing.T) {
+	t.Parallel()
+	orders := reverseOrders([]orderClause{
+		{column: "due", dir: Asc, nulls: NullsLast},
+		{column: "id", dir: Desc, nullsFirst: true},
+	})
+	if got := buildOrderSQL(Postgres(), orders); got != ` ORDER BY "due" DESC NULLS FIRST, "id" ASC` {
+		t.Errorf("got %s", got)
+	}
+	if orders[0].nullsFirst != true || orders[1].nullsFirst != false {
+		t.Errorf("null placement must flip with direction: %+v", orders)
+	}
+}
+
+func TestMemory_Paginate(t *testing.T) {
+	t.Parallel()
+	var users []memUser
+	for i := 1; i <= 6; i++ {
+		users = append(users, memUser{ID: i, Name: fmt.Sprintf("u%d", i), Age: i % 2})
+	}
+	m := newMemUsers(t, users...)
+	ctx := context.Background()
+	if err := m.Delete(ctx, 6); err != nil {
+		t.Fatal(err)
+	}
+	ids := func(p *OffsetPage[memUser]) []int {
+		var out []int
+		for _, u := range p.Items {
+			out = append(out, u.ID)
+		}
+		return out
+	}
+
+	page, err := m.Query(ctx).OrderBy("age", Asc).Paginate(2, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids(page), []int{1, 3}) || page.Total != 5 || page.TotalPages != 3 ||
+		page.Page != 2 || page.PerPage != 2 {
+		t.Errorf("unexpected page: %v %+v", ids(page), page)
+	}
+
+	last, _ := m.Query(ctx).OrderBy("age", Asc).Paginate(3, 2)
+	if !reflect.DeepEqual(ids(last), []int{5}) {
+		t.Errorf("unexpected last page: %v", ids(last))
+	}
+	beyond, err := m.Query(ctx).Paginate(4, 2)
+	if err != nil || len(beyond.Items) != 0 || beyond.Total != 5 {
+		t.Errorf("unexpected page beyond the end: %+v, %v", beyond, err)
+	}
+	filtered, _ := m.Query(ctx).Where(Eq("age", 0)).Paginate(1, 10)
+	if !reflect.DeepEqual(ids(filtered), []int{2, 4}) || filtered.TotalPages != 1 {
+		t.Errorf("unexpected filtered page: %v %+v", ids(filtered), filtered)
+	}
+
+	for _, args := range [][2]int64{{0, 10}, {1, 0}} {
+		if _, err := m.Query(ctx).Paginate(args[0], args[1]); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("Paginate(%d, %d): expected ErrInvalidPage, got %v", args[0], args[1], err)
+		}
+	}
+	for name, q := range map[string]*Query[memUser]{"limit": m.Query(ctx).Limit(1), "offset": m.Query(ctx).Offset(1)} {
+		if _, err := q.Paginate(1, 10); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("%s: expected ErrInvalidPage, got %v", name, err)
+		}
+		if _, err := q.PaginateWindow(1, 10); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("%s: expected ErrInvalidPage, got %v", name, err)
+		}
+	}
+
+	window, err := m.Query(ctx).OrderBy("age", Asc).PaginateWindow(2, 2)
+	if err != nil || !reflect.DeepEqual(ids(window), ids(page)) || window.Total != 5 || window.TotalPages != 3 {
+		t.Errorf("unexpected window page: %v %+v, %v", ids(window), window, err)
+	}
+	if beyond, err := m.Query(ctx).PaginateWindow(4, 2); err != nil || len(beyond.Items) != 0 || beyond.Total != 5 {
+		t.Errorf("unexpected window page beyond the end: %+v, %v", beyond, err)
+	}
+}
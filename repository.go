@@ -5,23 +5,33 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 )
 
 type Repository[T any] struct {
-	db      *sql.DB
-	table   Table
-	dialect Dialect
-	driver  driver[T]
-	logger  Logger
+	db        *sql.DB
+	table     Table
+	dialect   Dialect
+	driver    driver[T]
+	relations []Relation
+	encode    func(T) CompositeValues
+	logger    Logger
+	wrap      func(Executor) Executor
+	columns   *columnResolver
+	key       []byte
+	base      *viewBase
 }
 
 func New[T any](db *sql.DB, dialect Dialect, mapping Mapping[T]) *Repository[T] {
 	m := mapping.configure(dialect)
 	return &Repository[T]{
-		db:      db,
-		table:   m.table,
-		dialect: dialect,
-		driver:  m.driver,
+		db:        db,
+		table:     m.table,
+		dialect:   dialect,
+		driver:    m.driver,
+		relations: m.codec.relations,
+		encode:    m.codec.encode,
 	}
 }
 
@@ -31,11 +41,36 @@ func (r *Repository[T]) WithLogger(l Logger) *Repository[T] {
 	return &copy
 }
 
+func (r *Repository[T]) WithExecutor(wrap func(Executor) Executor) *Repository[T] {
+	copy := *r
+	copy.wrap = wrap
+	return &copy
+}
+
+func (r *Repository[T]) WithColumnWhitelist(w ColumnWhitelist) *Repository[T] {
+	copy := *r
+	copy.columns = newColumnResolver(r.table, r.relations, w)
+	return &copy
+}
+
+func (r *Repository[T]) WithCursorKey(key []byte) *Repository[T] {
+	copy := *r
+	copy.key = key
+	return &copy
+}
+
 func (r *Repository[T]) exec() Executor {
+	return r.wrapExec(r.db)
+}
+
+func (r *Repository[T]) wrapExec(exec Executor) Executor {
 	if r.logger != nil {
-		return &loggingExecutor{inner: r.db, logger: r.logger}
+		exec = &loggingExecutor{inner: exec, logger: r.logger}
 	}
-	return r.db
+	if r.wrap != nil {
+		exec = r.wrap(exec)
+	}
+	return exec
 }
 
 func (r *Repository[T]) txBeginner() TxBeginner {
@@ -45,6 +80,12 @@ func (r *Repository[T]) txBeginner() TxBeginner {
 	return r.db
 }
 
+func (r *Repository[T]) inTx(ctx context.Context, fn func(Executor) error) error {
+	return inTx(ctx, r.txBeginner(), func(tx *sql.Tx) error {
+		return fn(r.wrapExec(tx))
+	})
+}
+
 func (r *Repository[T]) Find(ctx context.Context, ids ...any) (T, error) {
 	var zero T
 	if len(ids) != len(r.table.PrimaryKey) {
@@ -52,10 +93,7 @@ func (r *Repository[T]) Find(ctx context.Context, ids ...any) (T, error) {
 			len(r.table.PrimaryKey), len(ids))
 	}
 
-	pkSpec := r.buildPKSpec(ids)
-	spec := r.withSoftDelete(pkSpec)
-	condition, args, _ := spec.ToSQL(r.dialect, 1)
-	query := r.table.selectWhere(condition)
+	query, args := r.selectSQL(r.buildPKSpec(ids), nil, nil, nil)
 
 	agg, err := r.driver.findOne(ctx, r.exec(), query, args)
 	if err != nil {
@@ -68,72 +106,35 @@ func (r *Repository[T]) Find(ctx context.Context, ids ...any) (T, error) {
 }
 
 func (r *Repository[T]) FindBy(ctx context.Context, s Spec) ([]T, error) {
-	s = r.withSoftDelete(s)
-
-	var query string
-	var args []any
-
-	if s != nil {
-		condition, a, _ := s.ToSQL(r.dialect, 1)
-		args = a
-		query = r.table.selectWhere(condition)
-	} else {
-		query = r.table.selectFrom()
+	s, err := r.prepareSpec(s)
+	if err != nil {
+		return nil, err
 	}
-
-	return r.driver.findMany(ctx, r.exec(), query, args)
+	return r.selectItems(ctx, s, nil, nil, nil)
 }
 
 func (r *Repository[T]) ExistsBy(ctx context.Context, s Spec) (bool, error) {
-	s = r.withSoftDelete(s)
-
-	var query string
-	var args []any
-
-	if s != nil {
-		condition, a, _ := s.ToSQL(r.dialect, 1)
-		args = a
-		query = fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s)", r.table.Name, condition)
-	} else {
-		query = fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s)", r.table.Name)
+	s, err := r.prepareSpec(s)
+	if err != nil {
+		return false, err
 	}
-
-	exec := r.exec()
-	var exists bool
-	err := exec.QueryRowContext(ctx, query, args...).Scan(&exists)
-	return exists, err
+	return r.exists(ctx, s)
 }
 
 func (r *Repository[T]) CountBy(ctx context.Context, s Spec) (int64, error) {
-	s = r.withSoftDelete(s)
-
-	var query string
-	var args []any
-
-	if s != nil {
-		condition, a, _ := s.ToSQL(r.dialect, 1)
-		args = a
-		query = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", r.table.Name, condition)
-	} else {
-		query = fmt.Sprintf("SELECT COUNT(*) FROM %s", r.table.Name)
+	s, err := r.prepareSpec(s)
+	if err != nil {
+		return 0, err
 	}
-
-	exec := r.exec()
-	var count int64
-	err := exec.QueryRowContext(ctx, query, args...).Scan(&count)
-	return count, err
+	return r.count(ctx, s)
 }
 
 func (r *Repository[T]) Save(ctx context.Context, aggregate T) error {
-	return r.driver.save(ctx, r.txBeginner(), r.exec(), aggregate)
+	return r.driver.save(ctx, r.inTx, r.exec(), aggregate)
 }
 
 func (r *Repository[T]) SaveTx(ctx context.Context, tx *sql.Tx, aggregate T) error {
-	var exec Executor = tx
-	if r.logger != nil {
-		exec = &loggingExecutor{inner: tx, logger: r.logger}
-	}
-	return r.driver.save(ctx, nil, exec, aggregate)
+	return r.driver.save(ctx, nil, r.wrapExec(tx), aggregate)
 }
 
 func (r *Repository[T]) Delete(ctx context.Context, ids ...any) error {
@@ -141,7 +142,7 @@ func (r *Repository[T]) Delete(ctx context.Context, ids ...any) error {
 		return fmt.Errorf("expected %d primary key value(s), got %d",
 			len(r.table.PrimaryKey), len(ids))
 	}
-	return r.driver.delete(ctx, r.txBeginner(), r.exec(), ids)
+	return r.driver.delete(ctx, r.inTx, r.exec(), ids)
 }
 
 func (r *Repository[T]) DeleteTx(ctx context.Context, tx *sql.Tx, ids ...any) error {
@@ -149,21 +150,312 @@ func (r *Repository[T]) DeleteTx(ctx context.Context, tx *sql.Tx, ids ...any) er
 		return fmt.Errorf("expected %d primary key value(s), got %d",
 			len(r.table.PrimaryKey), len(ids))
 	}
-	var exec Executor = tx
-	if r.logger != nil {
-		exec = &loggingExecutor{inner: tx, logger: r.logger}
-	}
-	return r.driver.delete(ctx, nil, exec, ids)
+	return r.driver.delete(ctx, nil, r.wrapExec(tx), ids)
 }
 
 func (r *Repository[T]) Query(ctx context.Context) *Query[T] {
 	return &Query[T]{
-		repo:    r,
+		src:     r,
 		ctx:     ctx,
 		forward: true,
 	}
 }
 
+func (r *Repository[T]) NewQuery() *Query[T] {
+	return &Query[T]{src: r, forward: true}
+}
+
+func (r *Repository[T]) queryDialect() Dialect { return r.dialect }
+
+func (r *Repository[T]) primaryKey() []string { return r.table.PrimaryKey }
+
+func (r *Repository[T]) nullable(column string) bool {
+	return slices.Contains(r.table.Nullable, column)
+}
+
+func (r *Repository[T]) searchTable() string {
+	if r.base != nil {
+		return ""
+	}
+	return r.table.Name
+}
+
+func (r *Repository[T]) prepareSpec(s Spec) (Spec, error) {
+	s, err := bindRelations(s, r.table, r.relations)
+	if err != nil {
+		return nil, err
+	}
+	if s, err = bindTextSearch(s, r.searchTable()); err != nil {
+		return nil, err
+	}
+	if err := Validate(s); err != nil {
+		return nil, err
+	}
+	if err := validateDialect(s, r.dialect); err != nil {
+		return nil, err
+	}
+	if s == nil || r.columns == nil {
+		return s, nil
+	}
+	if err := r.columns.check(s); err != nil {
+		return nil, err
+	}
+	return mapOuterColumns(s, r.columns.resolve)
+}
+
+func (r *Repository[T]) prepareOrders(orders []orderClause) ([]orderClause, error) {
+	resolved, err := resolveOrders(orders, r.columns, r.dialect)
+	if err != nil {
+		return nil, err
+	}
+	for i, o := range resolved {
+		if o.rank != nil {
+			resolved[i].rank = o.rank.bind(r.searchTable())
+			if err := resolved[i].rank.checkDialect(r.dialect); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resolved, nil
+}
+
+func (r *Repository[T]) selectItems(
+	ctx context.Context, s Spec, orders []orderClause, limit, offset *int64,
+) ([]T, error) {
+	query, args := r.selectSQL(s, orders, limit, offset)
+	return r.driver.findMany(ctx, r.exec(), query, args)
+}
+
+func (r *Repository[T]) selectCounted(
+	ctx context.Context, s Spec, orders []orderClause, limit, offset *int64,
+) ([]T, int64, error) {
+	selectList := strings.Join(quoteIdents(r.dialect, r.table.Columns), ", ") + ", COUNT(*) OVER ()"
+	query, args := r.buildSelect(selectList, s, orders, limit, offset)
+	return r.driver.findManyTotal(ctx, r.exec(), query, args)
+}
+
+func (r *Repository[T]) selectSQL(s Spec, orders []orderClause, limit, offset *int64) (string, []any) {
+	return r.buildSelect(strings.Join(quoteIdents(r.dialect, r.table.Columns), ", "), s, orders, limit, offset)
+}
+
+func (r *Repository[T]) buildSelect(
+	selectList string, s Spec, orders []orderClause, limit, offset *int64,
+) (string, []any) {
+	d := r.dialect
+	from, args, nextParam := r.fromWhere(s)
+	query := "SELECT " + selectList + " FROM " + from
+
+	orderSQL, orderArgs, nextParam := renderOrderSQL(d, orders, nextParam)
+	query += orderSQL
+	args = append(args, orderArgs...)
+
+	if limit != nil {
+		query += fmt.Sprintf(" LIMIT %s", d.Placeholder(nextParam))
+		args = append(args, *limit)
+		nextParam++
+	}
+	if offset != nil {
+		query += fmt.Sprintf(" OFFSET %s", d.Placeholder(nextParam))
+		args = append(args, *offset)
+	}
+	return query, args
+}
+
+func (r *Repository[T]) fromWhere(s Spec) (string, []any, int) {
+	from := quoteIdent(r.dialect, r.table.Name)
+	var args []any
+	if r.base != nil {
+		from = "(" + r.base.sql + ") AS " + from
+		args = append(args, r.base.args...)
+	}
+	nextParam := len(args) + 1
+	if s = r.withSoftDelete(s); s != nil {
+		condition, specArgs, np := s.ToSQL(r.dialect, nextParam)
+		from += " WHERE " + condition
+		args = append(args, specArgs...)
+		nextParam = np
+	}
+	return from, args, nextParam
+}
+
+func (r *Repository[T]) project(
+	ctx context.Context, s Spec, p projection, orders []orderClause, limit, offset *int64,
+) ([][]any, error) {
+	query, args, err := r.projectSQL(s, p, orders, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return r.queryValues(ctx, len(p.columns), query, args)
+}
+
+func (r *Repository[T]) projectSQL(
+	s Spec, p projection, orders []orderClause, limit, offset *int64,
+) (string, []any, error) {
+	items := make([]string, len(p.columns))
+	for i, col := range p.columns {
+		resolved, err := r.resolveColumn(col)
+		if err != nil {
+			return "", nil, err
+		}
+		items[i] = quoteIdent(r.dialect, resolved)
+	}
+	selectList := strings.Join(items, ", ")
+	if p.distinct {
+		selectList = "DISTINCT " + selectList
+	}
+	query, args := r.buildSelect(selectList, s, orders, limit, offset)
+	return query, args, nil
+}
+
+func (r *Repository[T]) queryValues(ctx context.Context, width int, query string, args []any) ([][]any, error) {
+	rows, err := r.exec().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var result [][]any
+	for rows.Next() {
+		row := make([]any, width)
+		dest := make([]any, width)
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func (r *Repository[T]) cursorValues(item T, orders []orderClause) (map[string]any, error) {
+	return encodeCursorValues(r.encode, r.table.Columns, item, orders)
+}
+
+func (r *Repository[T]) cursorKey() []byte { return r.key }
+
+func (r *Repository[T]) count(ctx context.Context, s Spec) (int64, error) {
+	from, args, _ := r.fromWhere(s)
+	var count int64
+	err := r.exec().QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from, args...).Scan(&count)
+	return count, err
+}
+
+func (r *Repository[T]) exists(ctx context.Context, s Spec) (bool, error) {
+	from, args, _ := r.fromWhere(s)
+	var exists bool
+	err := r.exec().QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+from+")", args...).Scan(&exists)
+	return exists, err
+}
+
+func (r *Repository[T]) aggregate(ctx context.Context, s Spec, a aggregateQuery) ([][]any, error) {
+	query, args, err := r.aggregateSQL(s, a)
+	if err != nil {
+		return nil, err
+	}
+	return r.queryValues(ctx, len(a.columns()), query, args)
+}
+
+func (r *Repository[T]) aggregateSQL(s Spec, a aggregateQuery) (string, []any, error) {
+	d := r.dialect
+	if err := validateDialect(a.having, d); err != nil {
+		return "", nil, err
+	}
+	items := make([]string, 0, len(a.groupBy)+len(a.aggregates))
+	group := make([]string, len(a.groupBy))
+	for i, col := range a.groupBy {
+		resolved, err := r.resolveColumn(col)
+		if err != nil {
+			return "", nil, err
+		}
+		group[i] = quoteIdent(d, resolved)
+		items = append(items, group[i]+" AS "+quoteIdent(d, col))
+	}
+	for _, agg := range a.aggregates {
+		if agg.column != "" {
+			var err error
+			if agg.column, err = r.resolveColumn(agg.column); err != nil {
+				return "", nil, err
+			}
+		}
+		items = append(items, agg.sql(d)+" AS "+quoteIdent(d, agg.alias))
+	}
+
+	from, args, next := r.fromWhere(s)
+	query := "SELECT " + strings.Join(items, ", ") + " FROM " + from
+	if len(group) > 0 {
+		query += " GROUP BY " + strings.Join(group, ", ")
+	}
+	if a.having == nil && len(a.orders) == 0 {
+		return query, args, nil
+	}
+
+	query = fmt.Sprintf("SELECT * FROM (%s) AS %s", query, d.QuoteIdent("grouped"))
+	if a.having != nil {
+		condition, havingArgs, np := a.having.ToSQL(d, next)
+		query += " WHERE " + condition
+		args = append(args, havingArgs...)
+		next = np
+	}
+	orderSQL, orderArgs, _ := renderOrderSQL(d, a.orders, next)
+	return query + orderSQL, append(args, orderArgs...), nil
+}
+
+func (r *Repository[T]) resolveColumn(column string) (string, error) {
+	if r.columns == nil {
+		return column, nil
+	}
+	return r.columns.resolve(column)
+}
+
+func resolveOrders(orders []orderClause, columns *columnResolver, d Dialect) ([]orderClause, error) {
+	resolved := make([]orderClause, len(orders))
+	for i, o := range orders {
+		if o.dir != Asc && o.dir != Desc {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidDirection, o.dir)
+		}
+		if o.nulls != "" && o.nulls != NullsFirst && o.nulls != NullsLast {
+			return nil, fmt.Errorf("%w: nulls %q", ErrInvalidDirection, o.nulls)
+		}
+		o.nullsFirst = o.nulls == NullsFirst || o.nulls == "" && defaultNullsFirst(d, string(o.dir))
+		var err error
+		switch {
+		case o.rank != nil:
+			if err := o.rank.validate(); err != nil {
+				return nil, err
+			}
+			if columns != nil {
+				mapped, err := o.rank.mapColumns(columns.resolve)
+				if err != nil {
+					return nil, err
+				}
+				o.rank = mapped.(*textSearchSpec)
+			}
+		case o.expr != nil:
+			if err := validateExprs(o.expr); err != nil {
+				return nil, err
+			}
+			if columns != nil {
+				if o.expr, err = mapExprColumns(o.expr, columns.resolve); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			if err := checkIdent("column", o.column); err != nil {
+				return nil, err
+			}
+			if columns != nil {
+				if o.column, err = columns.resolve(o.column); err != nil {
+					return nil, err
+				}
+			}
+		}
+		resolved[i] = o
+	}
+	return resolved, nil
+}
+
 func (r *Repository[T]) withSoftDelete(s Spec) Spec {
 	if r.table.SoftDelete == "" {
 		return s
@@ -184,7 +184,7 @@ func TestRepository_WithSoftDelete_NilSpec(t *testing.T) {
 		t.Fatal("expected non-nil")
 	}
 	sql, _, _ := result.ToSQL(Postgres(), 1)
-	if sql != "del IS NULL" {
+	if sql != `"del" IS NULL` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -205,7 +205,7 @@ func TestRepository_Query_ReturnsQuery(t *testing.T) {
 		dialect: Postgres(),
 	}
 	q := r.Query(context.TODO())
-	if q == nil || q.repo != r || !q.forward {
+	if q == nil || q.src != r || !q.forward {
 		t.Error("invalid query")
 	}
 }
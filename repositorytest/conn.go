@@ -0,0 +1,198 @@
+package repositorytest
+
+import (
+	"context"
+	"database/sql"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+var (
+	ErrNoQueryResult = errors.New("repositorytest: no more query results")
+	ErrNoExecResult  = errors.New("repositorytest: no more exec results")
+)
+
+const (
+	Begin    Kind = "begin"
+	Commit   Kind = "commit"
+	Rollback Kind = "rollback"
+)
+
+type QueryResult struct {
+	Columns []string
+	Rows    [][]sqlDriver.Value
+	Err     error
+}
+
+type ExecResult struct {
+	LastInsertID int64
+	RowsAffected int64
+	Err          error
+}
+
+type Conn struct {
+	Queries   []QueryResult
+	Execs     []ExecResult
+	BeginErr  error
+	CommitErr error
+
+	mu        sync.Mutex
+	qIdx      int
+	eIdx      int
+	calls     []Call
+	commits   int
+	rollbacks int
+}
+
+func NewDB(tb testing.TB, conn *Conn) *sql.DB {
+	tb.Helper()
+	db := sql.OpenDB(&connector{conn: conn})
+	db.SetMaxOpenConns(1)
+	tb.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func (c *Conn) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+func (c *Conn) Commits() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.commits
+}
+
+func (c *Conn) Rollbacks() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rollbacks
+}
+
+func (c *Conn) Prepare(query string) (sqlDriver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *Conn) Close() error { return nil }
+
+func (c *Conn) Begin() (sqlDriver.Tx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Kind: Begin})
+	if c.BeginErr != nil {
+		return nil, c.BeginErr
+	}
+	return &tx{conn: c}, nil
+}
+
+func (c *Conn) record(kind Kind, query string, args []sqlDriver.Value) {
+	values := make([]any, len(args))
+	for i, a := range args {
+		values[i] = a
+	}
+	c.calls = append(c.calls, Call{Kind: kind, SQL: query, Args: values})
+}
+
+type stmt struct {
+	conn  *Conn
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []sqlDriver.Value) (sqlDriver.Result, error) {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record(Exec, s.query, args)
+	if c.eIdx >= len(c.Execs) {
+		return nil, ErrNoExecResult
+	}
+	r := c.Execs[c.eIdx]
+	c.eIdx++
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return &result{lastID: r.LastInsertID, affected: r.RowsAffected}, nil
+}
+
+func (s *stmt) Query(args []sqlDriver.Value) (sqlDriver.Rows, error) {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record(Query, s.query, args)
+	if c.qIdx >= len(c.Queries) {
+		return nil, ErrNoQueryResult
+	}
+	r := c.Queries[c.qIdx]
+	c.qIdx++
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return &rows{columns: r.Columns, data: r.Rows}, nil
+}
+
+type rows struct {
+	columns []string
+	data    [][]sqlDriver.Value
+	pos     int
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []sqlDriver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.pos])
+	r.pos++
+	return nil
+}
+
+type result struct {
+	lastID   int64
+	affected int64
+}
+
+func (r *result) LastInsertId() (int64, error) { return r.lastID, nil }
+func (r *result) RowsAffected() (int64, error) { return r.affected, nil }
+
+type tx struct{ conn *Conn }
+
+func (t *tx) Commit() error {
+	c := t.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Kind: Commit})
+	if c.CommitErr != nil {
+		return c.CommitErr
+	}
+	c.commits++
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	c := t.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Kind: Rollback})
+	c.rollbacks++
+	return nil
+}
+
+type connector struct{ conn *Conn }
+
+func (c *connector) Connect(_ context.Context) (sqlDriver.Conn, error) { return c.conn, nil }
+func (c *connector) Driver() sqlDriver.Driver                          { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(_ string) (sqlDriver.Conn, error) {
+	return nil, errors.New("repositorytest: use NewDB")
+}
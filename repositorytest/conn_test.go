@@ -0,0 +1,85 @@
+package repositorytest
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/shuldan/repository"
+)
+
+var userTable = repository.Table{
+	Name:       "users",
+	PrimaryKey: []string{"id"},
+	Columns:    []string{"id", "name"},
+}
+
+type user struct{ ID, Name string }
+
+func newUserRepo(t *testing.T, conn *Conn) *repository.Repository[user] {
+	t.Helper()
+	return repository.New(NewDB(t, conn), repository.Postgres(), repository.Simple(repository.SimpleConfig[user]{
+		Table: userTable,
+		Scan: func(sc repository.Scanner) (user, error) {
+			var u user
+			err := sc.Scan(&u.ID, &u.Name)
+			return u, err
+		},
+		Values: func(u user) []any { return []any{u.ID, u.Name} },
+	}))
+}
+
+func TestConn_ScriptedResults(t *testing.T) {
+	t.Parallel()
+	conn := &Conn{
+		Queries: []QueryResult{{Columns: []string{"id", "name"}, Rows: [][]sqlDriver.Value{{"1", "alice"}}}},
+		Execs:   []ExecResult{{RowsAffected: 1}},
+	}
+	repo := newUserRepo(t, conn)
+	ctx := context.Background()
+
+	u, err := repo.Find(ctx, "1")
+	if err != nil || u.Name != "alice" {
+		t.Fatalf("unexpected: %+v, %v", u, err)
+	}
+	if err := repo.Save(ctx, user{ID: "2", Name: "bob"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.FindBy(ctx, nil); !errors.Is(err, ErrNoQueryResult) {
+		t.Errorf("expected ErrNoQueryResult, got %v", err)
+	}
+
+	calls := conn.Calls()
+	if len(calls) != 3 || calls[0].Kind != Query || calls[1].Kind != Exec {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	if calls[0].SQL != `SELECT "id", "name" FROM "users" WHERE "id" = $1` || calls[0].Args[0] != "1" {
+		t.Errorf("unexpected first call: %v", calls[0])
+	}
+}
+
+func TestConn_TransactionErrors(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("boom")
+	conn := &Conn{BeginErr: boom}
+	db := NewDB(t, conn)
+	if _, err := db.Begin(); !errors.Is(err, boom) {
+		t.Errorf("expected begin error, got %v", err)
+	}
+
+	conn = &Conn{CommitErr: boom}
+	db = NewDB(t, conn)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, boom) {
+		t.Errorf("expected commit error, got %v", err)
+	}
+	tx, _ = db.Begin()
+	_ = tx.Rollback()
+	if conn.Commits() != 0 || conn.Rollbacks() != 1 {
+		t.Errorf("unexpected counters: %d commits, %d rollbacks", conn.Commits(), conn.Rollbacks())
+	}
+}
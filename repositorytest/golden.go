@@ -0,0 +1,69 @@
+package repositorytest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const UpdateEnv = "REPOSITORYTEST_UPDATE"
+
+func Golden(tb testing.TB, path string, calls []Call) {
+	tb.Helper()
+	got := FormatGolden(calls)
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("repositorytest: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o600); err != nil {
+			tb.Fatalf("repositorytest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("repositorytest: %v (run with %s=1 to create it)", err, UpdateEnv)
+	}
+	if diff := diffLines(string(want), got); diff != "" {
+		tb.Errorf("repositorytest: %s does not match recorded SQL (run with %s=1 to update):\n%s",
+			path, UpdateEnv, diff)
+	}
+}
+
+func FormatGolden(calls []Call) string {
+	var b strings.Builder
+	for _, c := range calls {
+		fmt.Fprintf(&b, "-- %s\n", c.Kind)
+		if c.SQL != "" {
+			b.WriteString(c.SQL + "\n")
+		}
+		for i, a := range c.Args {
+			fmt.Fprintf(&b, "-- $%d = %s\n", i+1, formatArg(a))
+		}
+	}
+	return b.String()
+}
+
+func diffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < max(len(wl), len(gl)); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return ""
+}
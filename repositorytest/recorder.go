@@ -0,0 +1,206 @@
+package repositorytest
+
+import (
+	"context"
+	"database/sql"
+	sqlDriver "database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shuldan/repository"
+)
+
+type Kind string
+
+const (
+	Query    Kind = "query"
+	QueryRow Kind = "query_row"
+	Exec     Kind = "exec"
+)
+
+type Call struct {
+	Kind Kind
+	SQL  string
+	Args []any
+}
+
+func (c Call) String() string {
+	if c.SQL == "" {
+		return string(c.Kind)
+	}
+	return fmt.Sprintf("%s %s %s", c.Kind, c.SQL, formatArgs(c.Args))
+}
+
+var AnyArg any = anyArg{}
+
+type anyArg struct{}
+
+type Expectation struct {
+	pattern *regexp.Regexp
+	args    []any
+	hasArgs bool
+}
+
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.args = args
+	e.hasArgs = true
+	return e
+}
+
+func (e *Expectation) String() string {
+	if e.hasArgs {
+		return fmt.Sprintf("/%s/ %s", e.pattern, formatArgs(e.args))
+	}
+	return "/" + e.pattern.String() + "/"
+}
+
+func (e *Expectation) matches(c Call) bool {
+	if c.SQL == "" || !e.pattern.MatchString(c.SQL) {
+		return false
+	}
+	if !e.hasArgs {
+		return true
+	}
+	if len(e.args) != len(c.Args) {
+		return false
+	}
+	for i, want := range e.args {
+		if want == AnyArg {
+			continue
+		}
+		if !reflect.DeepEqual(driverValue(want), driverValue(c.Args[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+type recording struct {
+	mu       sync.Mutex
+	calls    []Call
+	expected []*Expectation
+}
+
+type Recorder struct {
+	inner repository.Executor
+	rec   *recording
+}
+
+func NewRecorder(inner repository.Executor) *Recorder {
+	return &Recorder{inner: inner, rec: &recording{}}
+}
+
+func (r *Recorder) Wrap(inner repository.Executor) repository.Executor {
+	return &Recorder{inner: inner, rec: r.rec}
+}
+
+func (r *Recorder) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	r.record(Query, query, args)
+	return r.inner.QueryContext(ctx, query, args...)
+}
+
+func (r *Recorder) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	r.record(QueryRow, query, args)
+	return r.inner.QueryRowContext(ctx, query, args...)
+}
+
+func (r *Recorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.record(Exec, query, args)
+	return r.inner.ExecContext(ctx, query, args...)
+}
+
+func (r *Recorder) Calls() []Call {
+	r.rec.mu.Lock()
+	defer r.rec.mu.Unlock()
+	return append([]Call(nil), r.rec.calls...)
+}
+
+func (r *Recorder) Reset() {
+	r.rec.mu.Lock()
+	defer r.rec.mu.Unlock()
+	r.rec.calls = nil
+	r.rec.expected = nil
+}
+
+func (r *Recorder) Expect(pattern string) *Expectation {
+	e := &Expectation{pattern: regexp.MustCompile(pattern)}
+	r.rec.mu.Lock()
+	defer r.rec.mu.Unlock()
+	r.rec.expected = append(r.rec.expected, e)
+	return e
+}
+
+func (r *Recorder) Verify() error {
+	r.rec.mu.Lock()
+	defer r.rec.mu.Unlock()
+	next := 0
+	for _, e := range r.rec.expected {
+		for next < len(r.rec.calls) && !e.matches(r.rec.calls[next]) {
+			next++
+		}
+		if next == len(r.rec.calls) {
+			return fmt.Errorf("repositorytest: expected statement %s was not executed in order; recorded:\n%s",
+				e, formatCalls(r.rec.calls))
+		}
+		next++
+	}
+	return nil
+}
+
+func (r *Recorder) AssertExpectations(tb testing.TB) {
+	tb.Helper()
+	if err := r.Verify(); err != nil {
+		tb.Error(err)
+	}
+}
+
+func (r *Recorder) Golden(tb testing.TB, path string) {
+	tb.Helper()
+	Golden(tb, path, r.Calls())
+}
+
+func (r *Recorder) record(kind Kind, query string, args []any) {
+	r.rec.mu.Lock()
+	defer r.rec.mu.Unlock()
+	r.rec.calls = append(r.rec.calls, Call{Kind: kind, SQL: query, Args: append([]any(nil), args...)})
+}
+
+func driverValue(v any) any {
+	if dv, err := sqlDriver.DefaultParameterConverter.ConvertValue(v); err == nil {
+		return dv
+	}
+	return v
+}
+
+func formatArgs(args []any) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = formatArg(a)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func formatArg(a any) string {
+	switch v := driverValue(a).(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("x%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatCalls(calls []Call) string {
+	var b strings.Builder
+	for i, c := range calls {
+		fmt.Fprintf(&b, "  %d: %s\n", i+1, c)
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package repositorytest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shuldan/repository"
+)
+
+func TestRecorder_ExpectationsInOrder(t *testing.T) {
+	t.Parallel()
+	conn := &Conn{Execs: []ExecResult{{RowsAffected: 1}, {RowsAffected: 1}}}
+	rec := NewRecorder(nil)
+	repo := newUserRepo(t, conn).WithExecutor(rec.Wrap)
+	ctx := context.Background()
+
+	_ = repo.Save(ctx, user{ID: "1", Name: "alice"})
+	_ = repo.Delete(ctx, "1")
+
+	rec.Expect(`^INSERT INTO "users"`).WithArgs("1", AnyArg)
+	rec.Expect(`^DELETE FROM "users" WHERE "id" = \$1$`).WithArgs("1")
+	rec.AssertExpectations(t)
+
+	rec.Expect(`^INSERT`)
+	if err := rec.Verify(); err == nil || !strings.Contains(err.Error(), "/^INSERT/") {
+		t.Errorf("expected out-of-order expectation to fail, got %v", err)
+	}
+
+	rec.Reset()
+	if len(rec.Calls()) != 0 || rec.Verify() != nil {
+		t.Error("expected reset recorder")
+	}
+}
+
+func TestRecorder_ArgsMismatch(t *testing.T) {
+	t.Parallel()
+	conn := &Conn{Execs: []ExecResult{{RowsAffected: 1}}}
+	rec := NewRecorder(NewDB(t, conn))
+	if _, err := rec.ExecContext(context.Background(), "UPDATE t SET n = $1", 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rec.Expect(`UPDATE t`).WithArgs(int64(5))
+	if err := rec.Verify(); err != nil {
+		t.Errorf("int and int64 should compare equal: %v", err)
+	}
+	rec.Expect(`UPDATE t`).WithArgs(6)
+	if err := rec.Verify(); err == nil {
+		t.Error("expected args mismatch")
+	}
+}
+
+type order struct {
+	ID    string
+	Items []string
+}
+
+func TestRecorder_CompositeSaveRecordsTxStatements(t *testing.T) {
+	t.Parallel()
+	conn := &Conn{Execs: []ExecResult{{RowsAffected: 1}, {RowsAffected: 1}, {RowsAffected: 2}}}
+	rec := NewRecorder(nil)
+	repo := repository.New(NewDB(t, conn), repository.Postgres(), repository.Composite(repository.CompositeConfig[order, order]{
+		Table: repository.Table{Name: "orders", PrimaryKey: []string{"id"}, Columns: []string{"id"}},
+		Relations: []repository.Relation{{
+			Table: "items", ForeignKey: "order_id", Columns: []string{"order_id", "sku"},
+			OnSave: repository.DeleteAndReinsert,
+		}},
+		Decompose: func(o order) repository.CompositeValues {
+			rows := make([][]any, len(o.Items))
+			for i, sku := range o.Items {
+				rows[i] = []any{o.ID, sku}
+			}
+			return repository.CompositeValues{Root: []any{o.ID}, Children: map[string][][]any{"items": rows}}
+		},
+	})).WithExecutor(rec.Wrap)
+
+	if err := repo.Save(context.Background(), order{ID: "o1", Items: []string{"a", "b"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rec.Expect(`^INSERT INTO "orders"`).WithArgs("o1")
+	rec.Expect(`^DELETE FROM "items" WHERE "order_id" = \$1$`).WithArgs("o1")
+	rec.Expect(`^INSERT INTO "items"`).WithArgs("o1", "a", "o1", "b")
+	rec.AssertExpectations(t)
+
+	calls := conn.Calls()
+	if calls[0].Kind != Begin || calls[len(calls)-1].Kind != Commit || len(calls) != len(rec.Calls())+2 {
+		t.Errorf("expected statements inside one transaction, got %v", calls)
+	}
+}
+
+type captureTB struct {
+	testing.TB
+	errors []string
+}
+
+func (c *captureTB) Helper() {}
+
+func (c *captureTB) Errorf(format string, args ...any) {
+	c.errors = append(c.errors, format)
+}
+
+func TestGolden_CompareAndUpdate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "testdata", "save.sql")
+	calls := []Call{
+		{Kind: Begin},
+		{Kind: Exec, SQL: `DELETE FROM "items" WHERE "order_id" = $1`, Args: []any{"o1", nil}},
+	}
+
+	t.Setenv(UpdateEnv, "1")
+	Golden(t, path, calls)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden file not written: %v", err)
+	}
+	want := "-- begin\n-- exec\nDELETE FROM \"items\" WHERE \"order_id\" = $1\n-- $1 = \"o1\"\n-- $2 = NULL\n"
+	if string(data) != want {
+		t.Errorf("unexpected golden content:\n%s", data)
+	}
+
+	t.Setenv(UpdateEnv, "")
+	Golden(t, path, calls)
+
+	tb := &captureTB{TB: t}
+	calls[1].Args[0] = "o2"
+	Golden(tb, path, calls)
+	if len(tb.errors) != 1 {
+		t.Errorf("expected golden mismatch to be reported, got %v", tb.errors)
+	}
+}
+
+var _ repository.Executor = (*Recorder)(nil)
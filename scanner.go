@@ -6,6 +6,22 @@ type Scanner interface {
 	Scan(dest ...any) error
 }
 
+type totalScanner struct {
+	Scanner
+	total *int64
+}
+
+func (s totalScanner) Scan(dest ...any) error {
+	return s.Scanner.Scan(append(dest, s.total)...)
+}
+
+func withTotal(sc Scanner, total *int64) Scanner {
+	if total == nil {
+		return sc
+	}
+	return totalScanner{Scanner: sc, total: total}
+}
+
 type valuesScanner struct {
 	values []any
 }
@@ -16,6 +16,7 @@ type Table struct {
 	Name       string
 	PrimaryKey []string
 	Columns    []string
+	Nullable   []string
 
 	VersionColumn string
 	SoftDelete    string
@@ -36,12 +37,13 @@ type CompositeValues struct {
 	Children map[string][][]any
 }
 
-func (t Table) selectFrom() string {
-	return fmt.Sprintf("SELECT %s FROM %s", strings.Join(t.Columns, ", "), t.Name)
+func (t Table) selectFrom(d Dialect) string {
+	return fmt.Sprintf("SELECT %s FROM %s",
+		strings.Join(quoteIdents(d, t.Columns), ", "), quoteIdent(d, t.Name))
 }
 
-func (t Table) selectWhere(condition string) string {
-	return t.selectFrom() + " WHERE " + condition
+func (t Table) selectWhere(d Dialect, condition string) string {
+	return t.selectFrom(d) + " WHERE " + condition
 }
 
 func (t Table) upsertSQL(d Dialect) string {
@@ -55,28 +57,30 @@ func (t Table) upsertSQL(d Dialect) string {
 func (t Table) deleteSQL(d Dialect) string {
 	whereParts := make([]string, len(t.PrimaryKey))
 	for i, pk := range t.PrimaryKey {
-		whereParts[i] = fmt.Sprintf("%s = %s", pk, d.Placeholder(i+1))
+		whereParts[i] = fmt.Sprintf("%s = %s", quoteIdent(d, pk), d.Placeholder(i+1))
 	}
 	where := strings.Join(whereParts, " AND ")
+	name := quoteIdent(d, t.Name)
 
 	if t.SoftDelete != "" {
+		softDelete := quoteIdent(d, t.SoftDelete)
 		return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s AND %s IS NULL",
-			t.Name, t.SoftDelete, d.Now(), where, t.SoftDelete)
+			name, softDelete, d.Now(), where, softDelete)
 	}
-	return fmt.Sprintf("DELETE FROM %s WHERE %s", t.Name, where)
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", name, where)
 }
 
 func (r Relation) selectByFK(d Dialect) string {
 	return fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s",
-		strings.Join(r.Columns, ", "),
-		r.Table,
-		r.ForeignKey,
+		strings.Join(quoteIdents(d, r.Columns), ", "),
+		quoteIdent(d, r.Table),
+		quoteIdent(d, r.ForeignKey),
 		d.Placeholder(1))
 }
 
 func (r Relation) deleteByFK(d Dialect) string {
 	return fmt.Sprintf("DELETE FROM %s WHERE %s = %s",
-		r.Table, r.ForeignKey, d.Placeholder(1))
+		quoteIdent(d, r.Table), quoteIdent(d, r.ForeignKey), d.Placeholder(1))
 }
 
 func (r Relation) batchSelectByFKs(d Dialect, count int) string {
@@ -85,9 +89,9 @@ func (r Relation) batchSelectByFKs(d Dialect, count int) string {
 		placeholders[i] = d.Placeholder(i + 1)
 	}
 	return fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (%s)",
-		strings.Join(r.Columns, ", "),
-		r.Table,
-		r.ForeignKey,
+		strings.Join(quoteIdents(d, r.Columns), ", "),
+		quoteIdent(d, r.Table),
+		quoteIdent(d, r.ForeignKey),
 		strings.Join(placeholders, ", "))
 }
 
@@ -97,8 +101,8 @@ func (r Relation) insertSQL(d Dialect) string {
 		placeholders[i] = d.Placeholder(i + 1)
 	}
 	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		r.Table,
-		strings.Join(r.Columns, ", "),
+		quoteIdent(d, r.Table),
+		strings.Join(quoteIdents(d, r.Columns), ", "),
 		strings.Join(placeholders, ", "))
 }
 
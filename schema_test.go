@@ -16,8 +16,8 @@ func newTestTable() Table {
 func TestTable_SelectFrom(t *testing.T) {
 	t.Parallel()
 	tbl := newTestTable()
-	sql := tbl.selectFrom()
-	expected := "SELECT id, name, email FROM users"
+	sql := tbl.selectFrom(pgDialect())
+	expected := `SELECT "id", "name", "email" FROM "users"`
 	if sql != expected {
 		t.Errorf("expected %q, got %q", expected, sql)
 	}
@@ -26,8 +26,8 @@ func TestTable_SelectFrom(t *testing.T) {
 func TestTable_SelectWhere(t *testing.T) {
 	t.Parallel()
 	tbl := newTestTable()
-	sql := tbl.selectWhere("id = $1")
-	if !strings.HasSuffix(sql, " WHERE id = $1") {
+	sql := tbl.selectWhere(pgDialect(), `"id" = $1`)
+	if !strings.HasSuffix(sql, ` WHERE "id" = $1`) {
 		t.Errorf("expected WHERE clause, got %q", sql)
 	}
 }
@@ -36,7 +36,7 @@ func TestTable_UpsertSQL(t *testing.T) {
 	t.Parallel()
 	tbl := newTestTable()
 	sql := tbl.upsertSQL(Postgres())
-	if !strings.Contains(sql, "INSERT INTO users") {
+	if !strings.Contains(sql, `INSERT INTO "users"`) {
 		t.Errorf("expected INSERT, got %q", sql)
 	}
 }
@@ -45,7 +45,7 @@ func TestTable_DeleteSQL_Hard(t *testing.T) {
 	t.Parallel()
 	tbl := newTestTable()
 	sql := tbl.deleteSQL(Postgres())
-	if !strings.HasPrefix(sql, "DELETE FROM users") {
+	if !strings.HasPrefix(sql, `DELETE FROM "users"`) {
 		t.Errorf("expected DELETE, got %q", sql)
 	}
 }
@@ -55,7 +55,7 @@ func TestTable_DeleteSQL_Soft(t *testing.T) {
 	tbl := newTestTable()
 	tbl.SoftDelete = "deleted_at"
 	sql := tbl.deleteSQL(Postgres())
-	if !strings.HasPrefix(sql, "UPDATE users SET deleted_at") {
+	if !strings.HasPrefix(sql, `UPDATE "users" SET "deleted_at"`) {
 		t.Errorf("expected soft delete UPDATE, got %q", sql)
 	}
 	if !strings.Contains(sql, "IS NULL") {
@@ -67,7 +67,7 @@ func TestRelation_SelectByFK(t *testing.T) {
 	t.Parallel()
 	r := Relation{Table: "items", ForeignKey: "user_id", Columns: []string{"id", "user_id", "val"}}
 	sql := r.selectByFK(Postgres())
-	if !strings.Contains(sql, "WHERE user_id = $1") {
+	if !strings.Contains(sql, `WHERE "user_id" = $1`) {
 		t.Errorf("expected FK condition, got %q", sql)
 	}
 }
@@ -76,7 +76,7 @@ func TestRelation_DeleteByFK(t *testing.T) {
 	t.Parallel()
 	r := Relation{Table: "items", ForeignKey: "user_id"}
 	sql := r.deleteByFK(Postgres())
-	if !strings.Contains(sql, "DELETE FROM items WHERE user_id") {
+	if !strings.Contains(sql, `DELETE FROM "items" WHERE "user_id"`) {
 		t.Errorf("expected delete, got %q", sql)
 	}
 }
@@ -94,7 +94,7 @@ func TestRelation_InsertSQL(t *testing.T) {
 	t.Parallel()
 	r := Relation{Table: "items", Columns: []string{"id", "val"}}
 	sql := r.insertSQL(Postgres())
-	if !strings.Contains(sql, "INSERT INTO items") {
+	if !strings.Contains(sql, `INSERT INTO "items"`) {
 		t.Errorf("expected INSERT, got %q", sql)
 	}
 }
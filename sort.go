@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SortField struct {
+	Column string
+	Dir    Direction
+}
+
+type SortSpec []SortField
+
+func ParseSort(s string) (SortSpec, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	spec := make(SortSpec, 0, len(parts))
+	for _, part := range parts {
+		field := strings.TrimSpace(part)
+		dir := Asc
+		switch {
+		case strings.HasPrefix(field, "-"):
+			dir, field = Desc, field[1:]
+		case strings.HasPrefix(field, "+"):
+			field = field[1:]
+		}
+		if !isIdent(field) {
+			return nil, fmt.Errorf("%w: %q is not a column name", ErrInvalidSort, part)
+		}
+		spec = append(spec, SortField{Column: field, Dir: dir})
+	}
+	return spec, nil
+}
+
+func ParseDirection(s string) (Direction, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case string(Asc):
+		return Asc, nil
+	case string(Desc):
+		return Desc, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidDirection, s)
+}
+
+func (s SortSpec) String() string {
+	parts := make([]string, len(s))
+	for i, f := range s {
+		if f.Dir == Desc {
+			parts[i] = "-" + f.Column
+		} else {
+			parts[i] = f.Column
+		}
+	}
+	return strings.Join(parts, ",")
+}
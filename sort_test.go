@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSort_Valid(t *testing.T) {
+	t.Parallel()
+	spec, err := ParseSort("-created_at, name,+id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := SortSpec{{"created_at", Desc}, {"name", Asc}, {"id", Asc}}
+	if len(spec) != len(want) {
+		t.Fatalf("expected %d fields, got %d", len(want), len(spec))
+	}
+	for i := range want {
+		if spec[i] != want[i] {
+			t.Errorf("field %d: expected %+v, got %+v", i, want[i], spec[i])
+		}
+	}
+}
+
+func TestParseSort_Empty(t *testing.T) {
+	t.Parallel()
+	spec, err := ParseSort("  ")
+	if err != nil || spec != nil {
+		t.Errorf("expected nil spec, got %v, %v", spec, err)
+	}
+}
+
+func TestParseSort_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, in := range []string{"name,", "-", "name;DROP TABLE users", "lower(name)", "a.b"} {
+		if _, err := ParseSort(in); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("ParseSort(%q): expected ErrInvalidSort, got %v", in, err)
+		}
+	}
+}
+
+func TestSortSpec_String(t *testing.T) {
+	t.Parallel()
+	s := SortSpec{{"created_at", Desc}, {"name", Asc}}
+	if got := s.String(); got != "-created_at,name" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestParseDirection(t *testing.T) {
+	t.Parallel()
+	if d, err := ParseDirection("asc"); err != nil || d != Asc {
+		t.Errorf("expected Asc, got %v, %v", d, err)
+	}
+	if d, err := ParseDirection(" DESC "); err != nil || d != Desc {
+		t.Errorf("expected Desc, got %v, %v", d, err)
+	}
+	if _, err := ParseDirection("sideways"); !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("expected ErrInvalidDirection, got %v", err)
+	}
+}
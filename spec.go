@@ -11,21 +11,41 @@ type Spec interface {
 	ToSQL(d Dialect, offset int) (sql string, args []any, nextOffset int)
 }
 
+func invalidSQL(what string) string { return "<invalid " + what + ">" }
+
 type comparisonSpec struct {
 	column string
 	op     string
 	value  any
+	left   Expression
+}
+
+func Eq(column string, value any) Spec { return &comparisonSpec{column: column, op: "=", value: value} }
+func NotEq(column string, value any) Spec {
+	return &comparisonSpec{column: column, op: "!=", value: value}
+}
+func Gt(column string, value any) Spec { return &comparisonSpec{column: column, op: ">", value: value} }
+func Gte(column string, value any) Spec {
+	return &comparisonSpec{column: column, op: ">=", value: value}
+}
+func Lt(column string, value any) Spec { return &comparisonSpec{column: column, op: "<", value: value} }
+func Lte(column string, value any) Spec {
+	return &comparisonSpec{column: column, op: "<=", value: value}
 }
 
-func Eq(column string, value any) Spec    { return &comparisonSpec{column, "=", value} }
-func NotEq(column string, value any) Spec { return &comparisonSpec{column, "!=", value} }
-func Gt(column string, value any) Spec    { return &comparisonSpec{column, ">", value} }
-func Gte(column string, value any) Spec   { return &comparisonSpec{column, ">=", value} }
-func Lt(column string, value any) Spec    { return &comparisonSpec{column, "<", value} }
-func Lte(column string, value any) Spec   { return &comparisonSpec{column, "<=", value} }
+func columnSQL(d Dialect, column string, left Expression) string {
+	if left != nil {
+		return left.ExprSQL(d)
+	}
+	return quoteIdent(d, column)
+}
 
 func (s *comparisonSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
-	return fmt.Sprintf("%s %s %s", s.column, s.op, d.Placeholder(offset)),
+	left := columnSQL(d, s.column, s.left)
+	if e, ok := s.value.(Expression); ok {
+		return fmt.Sprintf("%s %s %s", left, s.op, e.ExprSQL(d)), nil, offset
+	}
+	return fmt.Sprintf("%s %s %s", left, s.op, d.Placeholder(offset)),
 		[]any{s.value}, offset + 1
 }
 
@@ -33,6 +53,7 @@ type inSpec struct {
 	column string
 	values []any
 	negate bool
+	left   Expression
 }
 
 func In(column string, values ...any) Spec {
@@ -58,7 +79,7 @@ func (s *inSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
 	if s.negate {
 		op = "NOT IN"
 	}
-	sql := fmt.Sprintf("%s %s (%s)", s.column, op, strings.Join(placeholders, ", "))
+	sql := fmt.Sprintf("%s %s (%s)", columnSQL(d, s.column, s.left), op, strings.Join(placeholders, ", "))
 	return sql, s.values, offset + len(s.values)
 }
 
@@ -66,6 +87,7 @@ type likeSpec struct {
 	column  string
 	pattern string
 	ilike   bool
+	left    Expression
 }
 
 func Like(column, pattern string) Spec {
@@ -77,18 +99,19 @@ func ILike(column, pattern string) Spec {
 }
 
 func (s *likeSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
-	op := likeOp
+	col, ph := columnSQL(d, s.column, s.left), d.Placeholder(offset)
+	sql := fmt.Sprintf("%s %s %s", col, likeOp, ph)
 	if s.ilike {
-		op = d.ILikeOp()
+		sql = foldSQL(d, foldLike, col, []string{ph})
 	}
-	return fmt.Sprintf("%s %s %s", s.column, op, d.Placeholder(offset)),
-		[]any{s.pattern}, offset + 1
+	return sql + likeEscape(d), []any{s.pattern}, offset + 1
 }
 
 type betweenSpec struct {
 	column string
 	from   any
 	to     any
+	left   Expression
 }
 
 func Between(column string, from, to any) Spec {
@@ -97,7 +120,7 @@ func Between(column string, from, to any) Spec {
 
 func (s *betweenSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
 	sql := fmt.Sprintf("%s BETWEEN %s AND %s",
-		s.column, d.Placeholder(offset), d.Placeholder(offset+1))
+		columnSQL(d, s.column, s.left), d.Placeholder(offset), d.Placeholder(offset+1))
 	return sql, []any{s.from, s.to}, offset + 2
 }
 
@@ -109,11 +132,11 @@ type nullSpec struct {
 func IsNull(column string) Spec    { return &nullSpec{column: column} }
 func IsNotNull(column string) Spec { return &nullSpec{column: column, not: true} }
 
-func (s *nullSpec) ToSQL(_ Dialect, offset int) (string, []any, int) {
+func (s *nullSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
 	if s.not {
-		return s.column + " IS NOT NULL", nil, offset
+		return quoteIdent(d, s.column) + " IS NOT NULL", nil, offset
 	}
-	return s.column + " IS NULL", nil, offset
+	return quoteIdent(d, s.column) + " IS NULL", nil, offset
 }
 
 type andSpec struct{ specs []Spec }
@@ -125,57 +148,42 @@ func Or(specs ...Spec) Spec  { return &orSpec{specs: specs} }
 func Not(spec Spec) Spec     { return &notSpec{spec: spec} }
 
 func (s *andSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
-	return joinSpecs(s.specs, " AND ", "TRUE", d, offset)
+	return renderSimplified(Simplify(s), d, offset)
 }
 
 func (s *orSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
-	return joinSpecs(s.specs, " OR ", "FALSE", d, offset)
+	return renderSimplified(Simplify(s), d, offset)
 }
 
 func (s *notSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
-	sql, args, next := s.spec.ToSQL(d, offset)
-	return "NOT (" + sql + ")", args, next
-}
-
-func joinSpecs(specs []Spec, sep, empty string, d Dialect, offset int) (string, []any, int) {
+	return renderSimplified(Simplify(s), d, offset)
+}
+
+func renderSimplified(s Spec, d Dialect, offset int) (string, []any, int) {
+	var specs []Spec
+	var sep, empty string
+	switch sp := s.(type) {
+	case *andSpec:
+		specs, sep, empty = sp.specs, " AND ", "TRUE"
+	case *orSpec:
+		specs, sep, empty = sp.specs, " OR ", "FALSE"
+	case *notSpec:
+		sql, args, next := renderSimplified(sp.spec, d, offset)
+		return "NOT (" + sql + ")", args, next
+	default:
+		return sp.ToSQL(d, offset)
+	}
 	if len(specs) == 0 {
 		return empty, nil, offset
 	}
-	if len(specs) == 1 {
-		return specs[0].ToSQL(d, offset)
-	}
 	parts := make([]string, 0, len(specs))
 	var allArgs []any
 	current := offset
 	for _, spec := range specs {
-		sql, args, next := spec.ToSQL(d, current)
+		sql, args, next := renderSimplified(spec, d, current)
 		parts = append(parts, "("+sql+")")
 		allArgs = append(allArgs, args...)
 		current = next
 	}
 	return strings.Join(parts, sep), allArgs, current
 }
-
-type rawSpec struct {
-	sql  string
-	args []any
-}
-
-func Raw(sql string, args ...any) Spec {
-	return &rawSpec{sql: sql, args: args}
-}
-
-func (s *rawSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
-	sql := s.sql
-	for i := len(s.args); i >= 1; i-- {
-		old := fmt.Sprintf("$%d", i)
-		placeholder := fmt.Sprintf("__RAW_%d__", i)
-		sql = strings.ReplaceAll(sql, old, placeholder)
-	}
-	for i := 1; i <= len(s.args); i++ {
-		placeholder := fmt.Sprintf("__RAW_%d__", i)
-		actual := d.Placeholder(offset + i - 1)
-		sql = strings.ReplaceAll(sql, placeholder, actual)
-	}
-	return sql, s.args, offset + len(s.args)
-}
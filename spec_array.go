@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	arrayContains = "contains"
+	arrayOverlaps = "overlaps"
+	arrayAny      = "any"
+)
+
+type arraySpec struct {
+	column string
+	op     string
+	values []any
+}
+
+func ArrayContains(column string, values ...any) Spec {
+	return &arraySpec{column: column, op: arrayContains, values: values}
+}
+
+func ArrayOverlaps(column string, values ...any) Spec {
+	return &arraySpec{column: column, op: arrayOverlaps, values: values}
+}
+
+func AnyEq(column string, value any) Spec {
+	return &arraySpec{column: column, op: arrayAny, values: []any{value}}
+}
+
+func (s *arraySpec) ToSQL(d Dialect, offset int) (string, []any, int) {
+	if len(s.values) == 0 {
+		if s.op == arrayContains {
+			return "TRUE", nil, offset
+		}
+		return "FALSE", nil, offset
+	}
+	var args []any
+	sql := arraySQL(d, s.op, quoteIdent(d, s.column), s.values, binder(d, offset, &args))
+	if sql == "" {
+		return "FALSE", nil, offset
+	}
+	return sql, args, offset + len(args)
+}
+
+func (s *arraySpec) checkDialect(d Dialect) error {
+	if arraySQL(d, s.op, s.column, s.values, func(any) string { return "?" }) == "" {
+		return fmt.Errorf("%w: array %s is not supported by %T", ErrUnsupportedSpec, s.op, d)
+	}
+	return nil
+}
+
+func (s *arraySpec) inspect() Node {
+	return Node{Kind: ArrayNode, Op: s.op, Column: s.column, Values: s.values}
+}
+
+func (s *arraySpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	col, err := fn(s.column)
+	if err != nil {
+		return nil, err
+	}
+	return &arraySpec{column: col, op: s.op, values: s.values}, nil
+}
+
+func binder(d Dialect, offset int, args *[]any) func(any) string {
+	return func(v any) string {
+		*args = append(*args, v)
+		return d.Placeholder(offset + len(*args) - 1)
+	}
+}
+
+func bindAll(values []any, bind func(any) string) string {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = bind(v)
+	}
+	return strings.Join(placeholders, ", ")
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestArraySpecs_ToSQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		spec Spec
+		d    Dialect
+		want string
+		args []any
+	}{
+		{ArrayContains("tags", "a", "b"), Postgres(), `"tags" @> ARRAY[$2, $3]`, []any{"a", "b"}},
+		{ArrayOverlaps("tags", "a"), Postgres(), `"tags" && ARRAY[$2]`, []any{"a"}},
+		{AnyEq("tags", "a"), Postgres(), `$2 = ANY("tags")`, []any{"a"}},
+		{ArrayContains("tags", "a", "b"), MySQL(), "JSON_CONTAINS(`tags`, JSON_ARRAY(?, ?))", []any{"a", "b"}},
+		{ArrayOverlaps("tags", "a"), MySQL(), "JSON_OVERLAPS(`tags`, JSON_ARRAY(?))", []any{"a"}},
+		{AnyEq("tags", "a"), MySQL(), "JSON_CONTAINS(`tags`, JSON_ARRAY(?))", []any{"a"}},
+		{ArrayContains("tags"), Postgres(), "TRUE", nil},
+		{ArrayOverlaps("tags"), Postgres(), "FALSE", nil},
+	}
+	for _, tt := range tests {
+		sql, args, next := tt.spec.ToSQL(tt.d, 2)
+		if sql != tt.want || !reflect.DeepEqual(args, tt.args) || next != 2+len(tt.args) {
+			t.Errorf("expected %s %v, got %s %v %d", tt.want, tt.args, sql, args, next)
+		}
+	}
+	if got := Simplify(And(Eq("a", 1), ArrayOverlaps("tags"))); !isConst(got, false) {
+		t.Errorf("expected FALSE, got %#v", got)
+	}
+}
+
+func TestJSONPathSpecs_ToSQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		spec Spec
+		d    Dialect
+		want string
+		args []any
+	}{
+		{JSONPathEq("attrs", "$.size.width", 10), Postgres(),
+			`jsonb_extract_path("attrs", $1, $2) = $3::jsonb`, []any{"size", "width", "10"}},
+		{JSONPathEq("attrs", `$.items[0]."sku code"`, "X"), Postgres(),
+			`jsonb_extract_path("attrs", $1, $2, $3) = $4::jsonb`, []any{"items", "0", "sku code", `"X"`}},
+		{JSONPathEq("attrs", "$.active", true), Postgres(),
+			`jsonb_extract_path("attrs", $1) = $2::jsonb`, []any{"active", "true"}},
+		{JSONHasKey("attrs", "color"), Postgres(), `jsonb_extract_path("attrs", $1) IS NOT NULL`, []any{"color"}},
+		{JSONPathEq("attrs", "$.color", "red"), MySQL(),
+			"JSON_UNQUOTE(JSON_EXTRACT(`attrs`, ?)) = ?", []any{"$.color", "red"}},
+		{JSONHasKey("attrs", "a-b"), MySQL(), "JSON_CONTAINS_PATH(`attrs`, 'one', ?)", []any{`$."a-b"`}},
+		{JSONPathEq("attrs", "$.color", "red"), SQLite(), `json_extract("attrs", ?) = ?`, []any{"$.color", "red"}},
+		{JSONHasPath("attrs", "$.a[1]"), SQLite(), `json_type("attrs", ?) IS NOT NULL`, []any{"$.a[1]"}},
+	}
+	for _, tt := range tests {
+		sql, args, _ := tt.spec.ToSQL(tt.d, 1)
+		if sql != tt.want || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("expected %s %v, got %s %v", tt.want, tt.args, sql, args)
+		}
+	}
+}
+
+func TestJSONPath_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, path := range []string{"", "$", "color", "$.", "$..a", "$[x]", "$[-1]", `$."a`, `$.""`, "$.a b"} {
+		if err := Validate(JSONPathEq("attrs", path, 1)); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%q: expected ErrInvalidSpec, got %v", path, err)
+		}
+	}
+	if err := Validate(JSONPathEq("attrs", "$.a", make(chan int))); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec, got %v", err)
+	}
+	if err := Validate(JSONHasKey("attrs", `a"b`)); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec, got %v", err)
+	}
+}
+
+func TestArraySpecs_UnsupportedDialect(t *testing.T) {
+	t.Parallel()
+	if _, ok := SQLite().(ArrayDialect); ok {
+		t.Error("SQLite must not implement ArrayDialect")
+	}
+	if sql, _, _ := AnyEq("tags", "a").ToSQL(SQLite(), 1); sql != "FALSE" {
+		t.Errorf("got %s", sql)
+	}
+	conn := &testConn{}
+	repo := newSimpleTestRepo(t, conn, simpleTable)
+	repo.dialect = SQLite()
+	_, err := repo.FindBy(context.Background(), Or(Eq("id", "x"), Not(AnyEq("tags", "a"))))
+	if !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+	if err := validateDialect(JSONHasKey("attrs", "a"), SQLite()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestArraySpecs_InspectAndMapColumns(t *testing.T) {
+	t.Parallel()
+	spec := And(ArrayContains("tags", "a"), JSONPathEq("attrs", "$.color", "red"))
+	if got := Columns(spec); !reflect.DeepEqual(got, []string{"tags", "attrs"}) {
+		t.Errorf("got %v", got)
+	}
+	if n := Inspect(JSONHasKey("attrs", "color")); n.Kind != JSONNode || !reflect.DeepEqual(n.Values, []any{"$.color"}) {
+		t.Errorf("got %+v", n)
+	}
+	mapped, err := MapColumns(spec, func(c string) (string, error) { return "p." + c, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `("p"."tags" @> ARRAY[$1]) AND (jsonb_extract_path("p"."attrs", $2) = $3::jsonb)`
+	if sql, _, _ := mapped.ToSQL(Postgres(), 1); sql != want {
+		t.Errorf("got %s", sql)
+	}
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	foldEq   = "="
+	foldIn   = "IN"
+	foldLike = "LIKE"
+)
+
+type foldSpec struct {
+	column string
+	op     string
+	values []any
+	left   Expression
+}
+
+func EqFold(column string, value string) Spec {
+	return &foldSpec{column: column, op: foldEq, values: []any{value}}
+}
+
+func InFold(column string, values ...string) Spec {
+	vals := make([]any, len(values))
+	for i, v := range values {
+		vals[i] = v
+	}
+	return &foldSpec{column: column, op: foldIn, values: vals}
+}
+
+func (s *foldSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
+	if len(s.values) == 0 {
+		return "FALSE", nil, offset
+	}
+	placeholders := make([]string, len(s.values))
+	for i := range s.values {
+		placeholders[i] = d.Placeholder(offset + i)
+	}
+	return foldSQL(d, s.op, columnSQL(d, s.column, s.left), placeholders), s.values, offset + len(s.values)
+}
+
+func (s *foldSpec) inspect() Node {
+	return Node{Kind: FoldNode, Op: s.op, Column: s.column, Values: s.values}
+}
+
+func (s *foldSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	col, err := fn(s.column)
+	if err != nil {
+		return nil, err
+	}
+	return &foldSpec{column: col, op: s.op, values: s.values}, nil
+}
+
+func (s *foldSpec) eval(row Row) (truth, error) {
+	v, err := rowValue(row, s.column)
+	if err != nil || v == nil {
+		return truthUnknown, err
+	}
+	str, ok := v.(string)
+	if !ok {
+		return truthUnknown, fmt.Errorf("%w: case-insensitive comparison of %T", ErrUnsupportedSpec, v)
+	}
+	for _, want := range s.values {
+		if w, ok := want.(string); ok && strings.EqualFold(str, w) {
+			return truthTrue, nil
+		}
+	}
+	return truthFalse, nil
+}
+
+func (s *foldSpec) Match(row Row) (bool, error) { return Match(s, row) }
+
+func lowerFoldSQL(op string, column string, placeholders []string) string {
+	lowered := make([]string, len(placeholders))
+	for i, ph := range placeholders {
+		lowered[i] = "LOWER(" + ph + ")"
+	}
+	if op == foldIn {
+		return "LOWER(" + column + ") IN (" + strings.Join(lowered, ", ") + ")"
+	}
+	return "LOWER(" + column + ") " + op + " " + lowered[0]
+}
+
+type collateSpec struct {
+	spec      Spec
+	collation string
+}
+
+func Collate(s Spec, collation string) Spec {
+	return &collateSpec{spec: s, collation: collation}
+}
+
+func (s *collateSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
+	collated, err := collateColumns(s.spec, s.collation)
+	if err != nil {
+		return invalidSQL("Collate"), nil, offset
+	}
+	return collated.ToSQL(d, offset)
+}
+
+func (s *collateSpec) validate() error {
+	if s.collation == "" || strings.IndexFunc(s.collation, func(r rune) bool {
+		return !(r == '_' || r == '-' || r == '.' || r == '@' ||
+			r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) >= 0 {
+		return fmt.Errorf("%w: collation %q", ErrInvalidSpec, s.collation)
+	}
+	_, err := collateColumns(s.spec, s.collation)
+	return err
+}
+
+type collateExpr struct {
+	expr      Expression
+	collation string
+}
+
+func (e collateExpr) ExprSQL(d Dialect) string {
+	return e.expr.ExprSQL(d) + " COLLATE " + d.QuoteIdent(e.collation)
+}
+
+func collateColumns(s Spec, collation string) (Spec, error) {
+	collate := func(column string, left Expression) Expression {
+		if left == nil {
+			left = Col(column)
+		}
+		return collateExpr{expr: left, collation: collation}
+	}
+	switch sp := s.(type) {
+	case *comparisonSpec:
+		c := *sp
+		c.left = collate(sp.column, sp.left)
+		if e, ok := sp.value.(Expression); ok {
+			c.value = collateExpr{expr: e, collation: collation}
+		}
+		return &c, nil
+	case *inSpec:
+		c := *sp
+		c.left = collate(sp.column, sp.left)
+		return &c, nil
+	case *likeSpec:
+		c := *sp
+		c.left = collate(sp.column, sp.left)
+		return &c, nil
+	case *betweenSpec:
+		c := *sp
+		c.left = collate(sp.column, sp.left)
+		return &c, nil
+	case *foldSpec:
+		c := *sp
+		c.left = collate(sp.column, sp.left)
+		return &c, nil
+	case *nullSpec, *collateSpec, *existsSpec, *inSelectSpec, *hasChildSpec:
+		return s, nil
+	case *andSpec, *orSpec, *notSpec:
+		children := Inspect(s).Children
+		collated := make([]Spec, len(children))
+		for i, child := range children {
+			c, err := collateColumns(child, collation)
+			if err != nil {
+				return nil, err
+			}
+			collated[i] = c
+		}
+		return s.(interface{ withChildren([]Spec) Spec }).withChildren(collated), nil
+	}
+	return nil, fmt.Errorf("%w: Collate does not apply to %T", ErrUnsupportedSpec, s)
+}
+
+func (s *collateSpec) inspect() Node {
+	return Node{Kind: CollateNode, Op: s.collation, Children: specList(s.spec)}
+}
+
+func (s *collateSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	inner, err := MapColumns(s.spec, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &collateSpec{spec: inner, collation: s.collation}, nil
+}
+
+func (s *collateSpec) eval(row Row) (truth, error) { return evalSpec(s.spec, row) }
+
+func (s *collateSpec) Match(row Row) (bool, error) { return Match(s, row) }
+
+func (s *collateSpec) withChildren(children []Spec) Spec {
+	return &collateSpec{spec: children[0], collation: s.collation}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func EscapeLike(s string) string { return likeEscaper.Replace(s) }
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFoldSpecs_ToSQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		spec Spec
+		d    Dialect
+		want string
+	}{
+		{EqFold("email", "A@B.C"), Postgres(), `LOWER("email") = LOWER($1)`},
+		{EqFold("email", "A@B.C"), MySQL(), "LOWER(`email`) = LOWER(?)"},
+		{EqFold("email", "A@B.C"), SQLite(), `"email" COLLATE NOCASE = ?`},
+		{InFold("code", "a", "b"), Postgres(), `LOWER("code") IN (LOWER($1), LOWER($2))`},
+		{InFold("code", "a", "b"), SQLite(), `"code" COLLATE NOCASE IN (?, ?)`},
+		{InFold("code"), Postgres(), "FALSE"},
+		{ILike("name", "a%"), SQLite(), `"name" LIKE ? ESCAPE '\'`},
+		{Like("name", "a%"), SQLite(), `"name" LIKE ? ESCAPE '\'`},
+		{Collate(Eq("name", "x"), "de-DE-x-icu"), Postgres(), `"name" COLLATE "de-DE-x-icu" = $1`},
+		{Collate(And(Eq("name", "x"), In("city", "a")), "utf8mb4_bin"), MySQL(),
+			"(`name` COLLATE `utf8mb4_bin` = ?) AND (`city` COLLATE `utf8mb4_bin` IN (?))"},
+		{Collate(GtCol("t.a", "b"), "NOCASE"), SQLite(), `"t"."a" COLLATE "NOCASE" > "b" COLLATE "NOCASE"`},
+		{Collate(And(Like("name", "a%"), Exists("orders", EqCol("orders.user_id", "users.id"))), "C"), Postgres(),
+			`("name" COLLATE "C" LIKE $1) AND (EXISTS (SELECT 1 FROM "orders" WHERE "orders"."user_id" = "users"."id"))`},
+		{Collate(Raw("name = ?", "x"), "C"), Postgres(), "<invalid Collate>"},
+	}
+	for _, tt := range tests {
+		if sql, _, _ := tt.spec.ToSQL(tt.d, 1); sql != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, sql)
+		}
+	}
+}
+
+func TestCollate_InspectAndValidate(t *testing.T) {
+	t.Parallel()
+	spec := Collate(And(EqFold("a", "x"), Eq("b", 1)), "C")
+	if n := Inspect(spec); n.Kind != CollateNode || n.Op != "C" || len(n.Children) != 1 {
+		t.Errorf("got %+v", n)
+	}
+	if got := Columns(spec); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("got %v", got)
+	}
+	mapped, err := PrefixColumns(spec, "u")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql, _, _ := mapped.ToSQL(Postgres(), 1); sql != `(LOWER("u"."a" COLLATE "C") = LOWER($1)) AND ("u"."b" COLLATE "C" = $2)` {
+		t.Errorf("got %s", sql)
+	}
+	for _, c := range []string{"", `x" ; DROP`, "a b"} {
+		if err := Validate(Collate(Eq("a", 1), c)); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%q: expected ErrInvalidSpec, got %v", c, err)
+		}
+	}
+}
+
+func TestCollate_RejectsUnsupportedSpecs(t *testing.T) {
+	t.Parallel()
+	for _, inner := range []Spec{Raw("name = ?", "x"), ArrayContains("tags", "a"), Not(&customSpec{})} {
+		if err := Validate(Collate(inner, "C")); !errors.Is(err, ErrUnsupportedSpec) {
+			t.Errorf("%T: expected ErrUnsupportedSpec, got %v", inner, err)
+		}
+	}
+}
+
+func TestEqFold_Match(t *testing.T) {
+	t.Parallel()
+	row := MapRow{"email": "Alice@Example.com", "n": 1}
+	if ok, err := Match(EqFold("email", "alice@example.COM"), row); err != nil || !ok {
+		t.Errorf("expected match, got %v, %v", ok, err)
+	}
+	if ok, err := Match(InFold("email", "bob", "ALICE@EXAMPLE.COM"), row); err != nil || !ok {
+		t.Errorf("expected match, got %v, %v", ok, err)
+	}
+	if _, err := Match(EqFold("n", "1"), row); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	t.Parallel()
+	if got := EscapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("got %q", got)
+	}
+	row := MapRow{"name": "50% off", "other": "500 off"}
+	spec := Like("name", "%"+EscapeLike("50%")+"%")
+	if ok, _ := Match(spec, row); !ok {
+		t.Error("expected literal match")
+	}
+	if ok, _ := Match(Like("other", EscapeLike("50%")+"%"), row); ok {
+		t.Error("escaped % must not act as a wildcard")
+	}
+}
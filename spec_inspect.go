@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+)
+
+type NodeKind string
+
+const (
+	CompareNode    NodeKind = "compare"
+	InNode         NodeKind = "in"
+	LikeNode       NodeKind = "like"
+	BetweenNode    NodeKind = "between"
+	NullNode       NodeKind = "null"
+	AndNode        NodeKind = "and"
+	OrNode         NodeKind = "or"
+	NotNode        NodeKind = "not"
+	RawNode        NodeKind = "raw"
+	ExistsNode     NodeKind = "exists"
+	InSelectNode   NodeKind = "in_select"
+	HasChildNode   NodeKind = "has_child"
+	ArrayNode      NodeKind = "array"
+	JSONNode       NodeKind = "json"
+	TextSearchNode NodeKind = "text_search"
+	FoldNode       NodeKind = "fold"
+	CollateNode    NodeKind = "collate"
+	CustomNode     NodeKind = "custom"
+)
+
+type Node struct {
+	Kind         NodeKind
+	Op           string
+	Column       string
+	Columns      []string
+	Left         Expression
+	Values       []any
+	Children     []Spec
+	SQL          string
+	Table        string
+	SelectColumn string
+	Spec         Spec
+}
+
+type Visitor func(n Node) bool
+
+type inspectable interface {
+	inspect() Node
+	mapColumns(fn func(string) (string, error)) (Spec, error)
+}
+
+func Inspect(s Spec) Node {
+	if i, ok := s.(inspectable); ok {
+		n := i.inspect()
+		n.Spec = s
+		return n
+	}
+	return Node{Kind: CustomNode, Spec: s}
+}
+
+func Walk(s Spec, v Visitor) {
+	if s == nil {
+		return
+	}
+	n := Inspect(s)
+	if !v(n) {
+		return
+	}
+	for _, child := range n.Children {
+		Walk(child, v)
+	}
+}
+
+func Validate(s Spec) error {
+	var err error
+	Walk(s, func(n Node) bool {
+		if err = validateNames(n); err != nil {
+			return false
+		}
+		if v, ok := n.Spec.(interface{ validate() error }); ok {
+			err = v.validate()
+		}
+		return err == nil
+	})
+	return err
+}
+
+func validateDialect(s Spec, d Dialect) error {
+	var err error
+	Walk(s, func(n Node) bool {
+		if c, ok := n.Spec.(interface{ checkDialect(Dialect) error }); ok && err == nil {
+			err = c.checkDialect(d)
+		}
+		return err == nil
+	})
+	return err
+}
+
+func nodeColumns(n Node) []string {
+	cols := slices.Clone(n.Columns)
+	if n.Column != "" {
+		cols = append(cols, n.Column)
+	}
+	if n.Left != nil {
+		cols = append(cols, exprColumns(n.Left)...)
+	}
+	for _, v := range n.Values {
+		if e, ok := v.(Expression); ok {
+			cols = append(cols, exprColumns(e)...)
+		}
+	}
+	return cols
+}
+
+func validateNames(n Node) error {
+	cols := nodeColumns(n)
+	if n.SelectColumn != "" {
+		cols = append(cols, n.SelectColumn)
+	}
+	for _, col := range cols {
+		if err := checkIdent("column", col); err != nil {
+			return err
+		}
+	}
+	if n.Table != "" {
+		return checkIdent("table", n.Table)
+	}
+	return nil
+}
+
+func Columns(s Spec) []string {
+	var cols []string
+	seen := make(map[string]bool)
+	add := func(col string) {
+		if col != "" && !seen[col] {
+			seen[col] = true
+			cols = append(cols, col)
+		}
+	}
+	Walk(s, func(n Node) bool {
+		add(n.Column)
+		for _, col := range n.Columns {
+			add(col)
+		}
+		if n.Left != nil {
+			for _, col := range exprColumns(n.Left) {
+				add(col)
+			}
+		}
+		for _, v := range n.Values {
+			if e, ok := v.(Expression); ok {
+				for _, col := range exprColumns(e) {
+					add(col)
+				}
+			}
+		}
+		return n.Table == ""
+	})
+	return cols
+}
+
+func MapColumns(s Spec, fn func(column string) (string, error)) (Spec, error) {
+	if i, ok := s.(inspectable); ok {
+		return i.mapColumns(fn)
+	}
+	return s, nil
+}
+
+func RenameColumns(s Spec, names map[string]string) (Spec, error) {
+	return MapColumns(s, func(col string) (string, error) {
+		if to, ok := names[col]; ok {
+			return to, nil
+		}
+		return col, nil
+	})
+}
+
+func PrefixColumns(s Spec, alias string) (Spec, error) {
+	return mapOuterColumns(s, func(col string) (string, error) {
+		if strings.Contains(col, ".") {
+			return col, nil
+		}
+		return alias + "." + col, nil
+	})
+}
+
+func transformSpec(s Spec, fn func(Spec) (Spec, error)) (Spec, error) {
+	if c, ok := s.(interface{ withChildren([]Spec) Spec }); ok {
+		children := Inspect(s).Children
+		mapped := make([]Spec, len(children))
+		changed := false
+		for i, child := range children {
+			m, err := transformSpec(child, fn)
+			if err != nil {
+				return nil, err
+			}
+			mapped[i] = m
+			changed = changed || m != child
+		}
+		if changed {
+			s = c.withChildren(mapped)
+		}
+	}
+	return fn(s)
+}
+
+func mapSpecList(specs []Spec, fn func(string) (string, error)) ([]Spec, error) {
+	mapped := make([]Spec, len(specs))
+	for i, s := range specs {
+		m, err := MapColumns(s, fn)
+		if err != nil {
+			return nil, err
+		}
+		mapped[i] = m
+	}
+	return mapped, nil
+}
+
+func (s *comparisonSpec) inspect() Node {
+	return Node{Kind: CompareNode, Op: s.op, Column: s.column, Left: s.left, Values: []any{s.value}}
+}
+
+func (s *comparisonSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	mapped := &comparisonSpec{column: s.column, op: s.op, value: s.value, left: s.left}
+	var err error
+	if s.left != nil {
+		if mapped.left, err = mapExprColumns(s.left, fn); err != nil {
+			return nil, err
+		}
+	} else if mapped.column, err = fn(s.column); err != nil {
+		return nil, err
+	}
+	if e, ok := s.value.(Expression); ok {
+		if mapped.value, err = mapExprColumns(e, fn); err != nil {
+			return nil, err
+		}
+	}
+	return mapped, nil
+}
+
+func (s *inSpec) inspect() Node {
+	op := "IN"
+	if s.negate {
+		op = "NOT IN"
+	}
+	return Node{Kind: InNode, Op: op, Column: s.column, Values: s.values}
+}
+
+func (s *inSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	col, err := fn(s.column)
+	if err != nil {
+		return nil, err
+	}
+	return &inSpec{column: col, values: s.values, negate: s.negate}, nil
+}
+
+func (s *likeSpec) inspect() Node {
+	op := likeOp
+	if s.ilike {
+		op = "ILIKE"
+	}
+	return Node{Kind: LikeNode, Op: op, Column: s.column, Values: []any{s.pattern}}
+}
+
+func (s *likeSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	col, err := fn(s.column)
+	if err != nil {
+		return nil, err
+	}
+	return &likeSpec{column: col, pattern: s.pattern, ilike: s.ilike}, nil
+}
+
+func (s *betweenSpec) inspect() Node {
+	return Node{Kind: BetweenNode, Op: "BETWEEN", Column: s.column, Values: []any{s.from, s.to}}
+}
+
+func (s *betweenSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	col, err := fn(s.column)
+	if err != nil {
+		return nil, err
+	}
+	return &betweenSpec{column: col, from: s.from, to: s.to}, nil
+}
+
+func (s *nullSpec) inspect() Node {
+	op := "IS NULL"
+	if s.not {
+		op = "IS NOT NULL"
+	}
+	return Node{Kind: NullNode, Op: op, Column: s.column}
+}
+
+func (s *nullSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	col, err := fn(s.column)
+	if err != nil {
+		return nil, err
+	}
+	return &nullSpec{column: col, not: s.not}, nil
+}
+
+func (s *andSpec) inspect() Node {
+	return Node{Kind: AndNode, Op: "AND", Children: s.specs}
+}
+
+func (s *andSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	specs, err := mapSpecList(s.specs, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &andSpec{specs: specs}, nil
+}
+
+func (s *orSpec) inspect() Node {
+	return Node{Kind: OrNode, Op: "OR", Children: s.specs}
+}
+
+func (s *orSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	specs, err := mapSpecList(s.specs, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &orSpec{specs: specs}, nil
+}
+
+func (s *notSpec) inspect() Node {
+	return Node{Kind: NotNode, Op: "NOT", Children: []Spec{s.spec}}
+}
+
+func (s *notSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	inner, err := MapColumns(s.spec, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &notSpec{spec: inner}, nil
+}
+
+func (s *rawSpec) inspect() Node {
+	return Node{Kind: RawNode, SQL: s.sql, Values: s.args, Children: s.subSpecs()}
+}
+
+func (s *rawSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	var args []any
+	for i, a := range s.args {
+		sub, ok := a.(Spec)
+		if !ok || sub == nil {
+			continue
+		}
+		mapped, err := MapColumns(sub, fn)
+		if err != nil {
+			return nil, err
+		}
+		if args == nil {
+			args = append([]any(nil), s.args...)
+		}
+		args[i] = mapped
+	}
+	if args == nil {
+		return s, nil
+	}
+	copy := *s
+	copy.args = args
+	return &copy, nil
+}
+
+func (s *andSpec) withChildren(specs []Spec) Spec { return &andSpec{specs: specs} }
+func (s *orSpec) withChildren(specs []Spec) Spec  { return &orSpec{specs: specs} }
+func (s *notSpec) withChildren(specs []Spec) Spec { return &notSpec{spec: specs[0]} }
+
+func (s *rawSpec) withChildren(specs []Spec) Spec {
+	copy := *s
+	copy.args = append([]any(nil), s.args...)
+	next := 0
+	for i, a := range copy.args {
+		if sub, ok := a.(Spec); ok && sub != nil {
+			copy.args[i] = specs[next]
+			next++
+		}
+	}
+	return &copy
+}
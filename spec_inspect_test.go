@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInspect_Nodes(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		spec Spec
+		kind NodeKind
+		op   string
+		col  string
+	}{
+		{Eq("a", 1), CompareNode, "=", "a"},
+		{NotIn("a", 1, 2), InNode, "NOT IN", "a"},
+		{ILike("a", "x%"), LikeNode, "ILIKE", "a"},
+		{Between("a", 1, 2), BetweenNode, "BETWEEN", "a"},
+		{IsNotNull("a"), NullNode, "IS NOT NULL", "a"},
+		{Or(Eq("a", 1)), OrNode, "OR", ""},
+		{Raw("a = $1", 1), RawNode, "", ""},
+		{&customSpec{}, CustomNode, "", ""},
+	}
+	for _, tc := range cases {
+		n := Inspect(tc.spec)
+		if n.Kind != tc.kind || n.Op != tc.op || n.Column != tc.col || n.Spec != tc.spec {
+			t.Errorf("unexpected node for %T: %+v", tc.spec, n)
+		}
+	}
+	if n := Inspect(Between("a", 1, 2)); !reflect.DeepEqual(n.Values, []any{1, 2}) {
+		t.Errorf("unexpected values: %v", n.Values)
+	}
+	if n := Inspect(Raw("a = $1", 1)); n.SQL != "a = $1" {
+		t.Errorf("unexpected raw SQL: %q", n.SQL)
+	}
+}
+
+func TestWalk_SkipsChildren(t *testing.T) {
+	t.Parallel()
+	spec := And(Eq("a", 1), Not(Or(Eq("b", 2), IsNull("c"))))
+	var kinds []NodeKind
+	Walk(spec, func(n Node) bool {
+		kinds = append(kinds, n.Kind)
+		return n.Kind != NotNode
+	})
+	want := []NodeKind{AndNode, CompareNode, NotNode}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("expected %v, got %v", want, kinds)
+	}
+	Walk(nil, func(Node) bool { t.Error("visitor called for nil spec"); return true })
+}
+
+func TestColumns(t *testing.T) {
+	t.Parallel()
+	spec := And(Eq("status", "a"), Or(Gt("amount", 1), Lt("amount", 0)), Not(IsNull("created_at")), Raw("x = 1"))
+	if got := Columns(spec); !reflect.DeepEqual(got, []string{"status", "amount", "created_at"}) {
+		t.Errorf("unexpected columns: %v", got)
+	}
+}
+
+func TestRenameAndPrefixColumns(t *testing.T) {
+	t.Parallel()
+	spec := And(Eq("name", "x"), Not(In("o.status", "a")), Like("email", "%@x"))
+
+	renamed, err := RenameColumns(spec, map[string]string{"name": "full_name"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := Columns(renamed); !reflect.DeepEqual(got, []string{"full_name", "o.status", "email"}) {
+		t.Errorf("unexpected renamed columns: %v", got)
+	}
+	prefixed, err := PrefixColumns(spec, "u")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, _, _ := prefixed.ToSQL(pgDialect(), 1)
+	if sql != `("u"."name" = $1) AND (NOT ("o"."status" IN ($2))) AND ("u"."email" LIKE $3)` {
+		t.Errorf("unexpected SQL: %s", sql)
+	}
+	if got := Columns(spec); got[0] != "name" {
+		t.Error("original spec must not be modified")
+	}
+}
+
+func TestMapColumns_Subqueries(t *testing.T) {
+	t.Parallel()
+	spec := And(
+		Exists("items", Eq("sku", "a")),
+		InSelect("id", "items", "order_id", Eq("qty", 1)),
+		HasChild("lines", Eq("price", 2)),
+	)
+	renamed, err := RenameColumns(spec, map[string]string{"sku": "code", "id": "order_id", "qty": "amount", "price": "cost"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, _, _ := And(renamed.(*andSpec).specs[:2]...).ToSQL(Postgres(), 1)
+	want := `(EXISTS (SELECT 1 FROM "items" WHERE "code" = $1)) AND ("order_id" IN (SELECT "order_id" FROM "items" WHERE "amount" = $2))`
+	if sql != want {
+		t.Errorf("unexpected SQL: %s", sql)
+	}
+	if got := Inspect(renamed.(*andSpec).specs[2]).Children[0]; Inspect(got).Column != "cost" {
+		t.Errorf("HasChild condition was not mapped: %+v", Inspect(got))
+	}
+
+	boom := errors.New("boom")
+	for _, s := range spec.(*andSpec).specs {
+		_, err := MapColumns(s, func(c string) (string, error) {
+			if c == "sku" || c == "qty" || c == "price" {
+				return "", boom
+			}
+			return c, nil
+		})
+		if !errors.Is(err, boom) {
+			t.Errorf("%T: expected error from subquery, got %v", s, err)
+		}
+	}
+
+	prefixed, err := PrefixColumns(spec, "o")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, _, _ = And(prefixed.(*andSpec).specs[:2]...).ToSQL(Postgres(), 1)
+	want = `(EXISTS (SELECT 1 FROM "items" WHERE "sku" = $1)) AND ("o"."id" IN (SELECT "order_id" FROM "items" WHERE "qty" = $2))`
+	if sql != want {
+		t.Errorf("prefix must only touch the outer query, got %s", sql)
+	}
+}
+
+func TestRenameColumns_PropagatesError(t *testing.T) {
+	t.Parallel()
+	if _, err := RenameColumns(&failingMapSpec{}, nil); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected error, got %v", err)
+	}
+	if _, err := PrefixColumns(And(&failingMapSpec{}), "u"); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected error, got %v", err)
+	}
+}
+
+type failingMapSpec struct{}
+
+func (*failingMapSpec) ToSQL(_ Dialect, offset int) (string, []any, int) { return "TRUE", nil, offset }
+func (*failingMapSpec) inspect() Node                                    { return Node{Kind: CustomNode} }
+func (*failingMapSpec) mapColumns(func(string) (string, error)) (Spec, error) {
+	return nil, ErrUnknownColumn
+}
+
+func TestMapColumns_Error(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("boom")
+	_, err := MapColumns(Or(Eq("a", 1), Not(Eq("b", 2))), func(c string) (string, error) {
+		if c == "b" {
+			return "", boom
+		}
+		return c, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("expected error to propagate, got %v", err)
+	}
+	custom := &customSpec{}
+	if mapped, err := MapColumns(custom, nil); err != nil || mapped != custom {
+		t.Error("custom specs should be returned unchanged")
+	}
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+type SpecJSONOptions struct {
+	Columns  []string
+	AllowRaw bool
+}
+
+var (
+	comparisonOps = map[string]string{"=": "eq", "!=": "ne", ">": "gt", ">=": "gte", "<": "lt", "<=": "lte"}
+	comparisonSQL = map[string]string{"eq": "=", "ne": "!=", "gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+)
+
+func MarshalSpec(s Spec, opts SpecJSONOptions) ([]byte, error) {
+	node, err := specToJSON(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+func UnmarshalSpec(data []byte, opts SpecJSONOptions) (Spec, error) {
+	dec := &specDecoder{allowed: makeSet(opts.Columns), allowRaw: opts.AllowRaw}
+	return dec.decode(data)
+}
+
+func specToJSON(s Spec, opts SpecJSONOptions) (map[string]any, error) {
+	switch sp := s.(type) {
+	case *comparisonSpec:
+		if _, ok := sp.value.(Expression); ok || sp.left != nil {
+			return nil, fmt.Errorf("%w: expressions are not serializable", ErrUnsupportedSpec)
+		}
+		return map[string]any{comparisonOps[sp.op]: []any{sp.column, sp.value}}, nil
+	case *inSpec:
+		key := "in"
+		if sp.negate {
+			key = "nin"
+		}
+		values := sp.values
+		if values == nil {
+			values = []any{}
+		}
+		return map[string]any{key: []any{sp.column, values}}, nil
+	case *likeSpec:
+		key := "like"
+		if sp.ilike {
+			key = "ilike"
+		}
+		return map[string]any{key: []any{sp.column, sp.pattern}}, nil
+	case *betweenSpec:
+		return map[string]any{"between": []any{sp.column, sp.from, sp.to}}, nil
+	case *nullSpec:
+		if sp.not {
+			return map[string]any{"notnull": sp.column}, nil
+		}
+		return map[string]any{"null": sp.column}, nil
+	case *andSpec:
+		return specListToJSON("and", sp.specs, opts)
+	case *orSpec:
+		return specListToJSON("or", sp.specs, opts)
+	case *notSpec:
+		inner, err := specToJSON(sp.spec, opts)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"not": inner}, nil
+	case *rawSpec:
+		if !opts.AllowRaw {
+			return nil, fmt.Errorf("%w: raw SQL is not serializable", ErrUnsupportedSpec)
+		}
+		if len(sp.subSpecs()) > 0 {
+			return nil, fmt.Errorf("%w: raw SQL with embedded specs is not serializable", ErrUnsupportedSpec)
+		}
+		if sp.names != nil {
+			return map[string]any{"rawnamed": []any{sp.sql, sp.params()}}, nil
+		}
+		return map[string]any{"raw": append([]any{sp.sql}, sp.args...)}, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedSpec, s)
+}
+
+func specListToJSON(key string, specs []Spec, opts SpecJSONOptions) (map[string]any, error) {
+	nodes := make([]any, len(specs))
+	for i, s := range specs {
+		n, err := specToJSON(s, opts)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = n
+	}
+	return map[string]any{key: nodes}, nil
+}
+
+type specDecoder struct {
+	allowed  map[string]bool
+	allowRaw bool
+}
+
+func (d *specDecoder) decode(data []byte) (Spec, error) {
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	if len(node) != 1 {
+		return nil, fmt.Errorf("%w: expected exactly one operator, got %d", ErrInvalidSpec, len(node))
+	}
+	for key, body := range node {
+		return d.decodeNode(key, body)
+	}
+	return nil, nil
+}
+
+func (d *specDecoder) decodeNode(key string, body json.RawMessage) (Spec, error) {
+	if op, ok := comparisonSQL[key]; ok {
+		col, args, err := d.operands(key, body, 1)
+		if err != nil {
+			return nil, err
+		}
+		return &comparisonSpec{column: col, op: op, value: args[0]}, nil
+	}
+	switch key {
+	case "in", "nin":
+		return d.decodeIn(key, body)
+	case "like", "ilike":
+		col, args, err := d.operands(key, body, 1)
+		if err != nil {
+			return nil, err
+		}
+		pattern, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s pattern must be a string", ErrInvalidSpec, key)
+		}
+		return &likeSpec{column: col, pattern: pattern, ilike: key == "ilike"}, nil
+	case "between":
+		col, args, err := d.operands(key, body, 2)
+		if err != nil {
+			return nil, err
+		}
+		return &betweenSpec{column: col, from: args[0], to: args[1]}, nil
+	case "null", "notnull":
+		return d.decodeNull(key, body)
+	case "and", "or":
+		return d.decodeList(key, body)
+	case "not":
+		inner, err := d.decode(body)
+		if err != nil {
+			return nil, err
+		}
+		return &notSpec{spec: inner}, nil
+	case "raw", "rawnamed":
+		return d.decodeRaw(key, body)
+	}
+	return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidSpec, key)
+}
+
+func (d *specDecoder) operands(key string, body json.RawMessage, n int) (string, []any, error) {
+	values, err := decodeValues(body)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s: %v", ErrInvalidSpec, key, err)
+	}
+	if len(values) != n+1 {
+		return "", nil, fmt.Errorf("%w: %s expects %d operands, got %d", ErrInvalidSpec, key, n+1, len(values))
+	}
+	col, err := d.column(values[0])
+	if err != nil {
+		return "", nil, err
+	}
+	if key != "in" && key != "nin" {
+		if err := checkScalars(key, values[1:]); err != nil {
+			return "", nil, err
+		}
+	}
+	return col, values[1:], nil
+}
+
+func checkScalars(key string, values []any) error {
+	for _, v := range values {
+		switch v.(type) {
+		case []any, map[string]any:
+			return fmt.Errorf("%w: %s operands must be scalars, got %T", ErrInvalidSpec, key, v)
+		}
+	}
+	return nil
+}
+
+func (d *specDecoder) column(v any) (string, error) {
+	col, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: column must be a string, got %T", ErrInvalidSpec, v)
+	}
+	if !d.allowed[col] {
+		return "", fmt.Errorf("%w: %q", ErrUnknownColumn, col)
+	}
+	return col, nil
+}
+
+func (d *specDecoder) decodeIn(key string, body json.RawMessage) (Spec, error) {
+	col, args, err := d.operands(key, body, 1)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := args[0].([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s values must be an array", ErrInvalidSpec, key)
+	}
+	if err := checkScalars(key, values); err != nil {
+		return nil, err
+	}
+	return &inSpec{column: col, values: values, negate: key == "nin"}, nil
+}
+
+func (d *specDecoder) decodeNull(key string, body json.RawMessage) (Spec, error) {
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSpec, key, err)
+	}
+	col, err := d.column(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &nullSpec{column: col, not: key == "notnull"}, nil
+}
+
+func (d *specDecoder) decodeList(key string, body json.RawMessage) (Spec, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSpec, key, err)
+	}
+	specs := make([]Spec, len(items))
+	for i, item := range items {
+		s, err := d.decode(item)
+		if err != nil {
+			return nil, err
+		}
+		specs[i] = s
+	}
+	if key == "or" {
+		return &orSpec{specs: specs}, nil
+	}
+	return &andSpec{specs: specs}, nil
+}
+
+func (d *specDecoder) decodeRaw(key string, body json.RawMessage) (Spec, error) {
+	if !d.allowRaw {
+		return nil, fmt.Errorf("%w: raw SQL is not allowed", ErrUnsupportedSpec)
+	}
+	values, err := decodeValues(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSpec, key, err)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%w: %s expects SQL text", ErrInvalidSpec, key)
+	}
+	sql, ok := values[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s SQL must be a string", ErrInvalidSpec, key)
+	}
+	var spec Spec
+	if key == "rawnamed" {
+		var params map[string]any
+		if len(values) == 2 {
+			params, _ = values[1].(map[string]any)
+		}
+		if params == nil {
+			return nil, fmt.Errorf("%w: rawnamed expects [sql, {params}]", ErrInvalidSpec)
+		}
+		for _, v := range params {
+			if err := checkScalars(key, []any{v}); err != nil {
+				return nil, err
+			}
+		}
+		spec = RawNamed(sql, params)
+	} else {
+		if err := checkScalars(key, values[1:]); err != nil {
+			return nil, err
+		}
+		spec = Raw(sql, values[1:]...)
+	}
+	if err := Validate(spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func decodeValues(body json.RawMessage) ([]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var values []any
+	if err := dec.Decode(&values); err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		values[i] = normalizeJSONValue(v)
+	}
+	return values, nil
+}
+
+func normalizeJSONValue(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	case []any:
+		for i, item := range val {
+			val[i] = normalizeJSONValue(item)
+		}
+		return val
+	case map[string]any:
+		for k, item := range val {
+			val[k] = normalizeJSONValue(item)
+		}
+		return val
+	}
+	return v
+}
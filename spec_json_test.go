@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var jsonOpts = SpecJSONOptions{Columns: []string{"status", "amount", "name", "created_at", "deleted_at"}}
+
+func TestSpecJSON_RoundTrip(t *testing.T) {
+	t.Parallel()
+	specs := []Spec{
+		Eq("status", "active"), NotEq("status", "banned"),
+		Gt("amount", int64(1)), Gte("amount", 1.5), Lt("amount", int64(10)), Lte("amount", int64(10)),
+		In("status", "a", "b"), NotIn("amount", int64(1), int64(2)), In("status"),
+		Like("name", "a%"), ILike("name", "%b"),
+		Between("created_at", "2024-01-01", "2024-12-31"),
+		IsNull("deleted_at"), IsNotNull("deleted_at"),
+		And(Eq("status", "active"), Or(Gt("amount", int64(100)), Not(IsNull("name")))),
+		And(), Or(),
+	}
+	for _, s := range specs {
+		data, err := MarshalSpec(s, jsonOpts)
+		if err != nil {
+			t.Fatalf("marshal %T: %v", s, err)
+		}
+		decoded, err := UnmarshalSpec(data, jsonOpts)
+		if err != nil {
+			t.Fatalf("unmarshal %s: %v", data, err)
+		}
+		wantSQL, wantArgs, _ := s.ToSQL(Postgres(), 1)
+		gotSQL, gotArgs, _ := decoded.ToSQL(Postgres(), 1)
+		if gotSQL != wantSQL || !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Errorf("%s: expected %q %v, got %q %v", data, wantSQL, wantArgs, gotSQL, gotArgs)
+		}
+	}
+}
+
+func TestMarshalSpec_Format(t *testing.T) {
+	t.Parallel()
+	data, err := MarshalSpec(And(Eq("status", "active")), jsonOpts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"and":[{"eq":["status","active"]}]}` {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestSpecJSON_RawExcludedByDefault(t *testing.T) {
+	t.Parallel()
+	if _, err := MarshalSpec(And(Raw("1=1")), jsonOpts); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("marshal: expected ErrUnsupportedSpec, got %v", err)
+	}
+	if _, err := UnmarshalSpec([]byte(`{"raw":["1=1"]}`), jsonOpts); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("unmarshal: expected ErrUnsupportedSpec, got %v", err)
+	}
+}
+
+func TestSpecJSON_RawAllowed(t *testing.T) {
+	t.Parallel()
+	opts := SpecJSONOptions{AllowRaw: true}
+	data, err := MarshalSpec(Raw("x > $1", int64(5)), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, err := UnmarshalSpec(data, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, args, _ := s.ToSQL(Postgres(), 1)
+	if sql != "x > $1" || !reflect.DeepEqual(args, []any{int64(5)}) {
+		t.Errorf("got %q %v", sql, args)
+	}
+}
+
+func TestUnmarshalSpec_ColumnAllowlist(t *testing.T) {
+	t.Parallel()
+	inputs := []string{
+		`{"eq":["password","x"]}`,
+		`{"and":[{"eq":["status","a"]},{"in":["role",["admin"]]}]}`,
+		`{"null":"secret"}`,
+		`{"not":{"like":["lower(name)","a"]}}`,
+	}
+	for _, in := range inputs {
+		if _, err := UnmarshalSpec([]byte(in), jsonOpts); !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("%s: expected ErrUnknownColumn, got %v", in, err)
+		}
+	}
+}
+
+func TestUnmarshalSpec_Invalid(t *testing.T) {
+	t.Parallel()
+	inputs := []string{
+		`not json`,
+		`{}`,
+		`{"eq":["status","a"],"ne":["status","b"]}`,
+		`{"xor":[]}`,
+		`{"eq":["status"]}`,
+		`{"eq":[1,"a"]}`,
+		`{"in":["status","a"]}`,
+		`{"like":["name",1]}`,
+		`{"null":1}`,
+		`{"and":{"eq":["status","a"]}}`,
+		`{"between":["amount",1]}`,
+		`{"eq":["status",{"a":1}]}`,
+		`{"eq":["status",["a"]]}`,
+		`{"between":["amount",[1],2]}`,
+		`{"in":["status",[["a"]]]}`,
+		`{"nin":["status",[{"a":1}]]}`,
+	}
+	for _, in := range inputs {
+		if _, err := UnmarshalSpec([]byte(in), jsonOpts); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%s: expected ErrInvalidSpec, got %v", in, err)
+		}
+	}
+}
+
+func TestUnmarshalSpec_RawRejectsNonScalarArgs(t *testing.T) {
+	t.Parallel()
+	opts := SpecJSONOptions{AllowRaw: true}
+	inputs := []string{
+		`{"raw":["x = ANY($1)",[1,2]]}`,
+		`{"rawnamed":["x = :v",{"v":{"a":1}}]}`,
+	}
+	for _, in := range inputs {
+		if _, err := UnmarshalSpec([]byte(in), opts); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%s: expected ErrInvalidSpec, got %v", in, err)
+		}
+	}
+}
+
+func TestUnmarshalSpec_NumberTypes(t *testing.T) {
+	t.Parallel()
+	s, err := UnmarshalSpec([]byte(`{"in":["amount",[1,2.5]]}`), jsonOpts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, args, _ := s.ToSQL(Postgres(), 1)
+	if !reflect.DeepEqual(args, []any{int64(1), 2.5}) {
+		t.Errorf("got %#v", args)
+	}
+}
+
+type customSpec struct{}
+
+func (customSpec) ToSQL(_ Dialect, offset int) (string, []any, int) { return "TRUE", nil, offset }
+
+func TestMarshalSpec_UnknownSpec(t *testing.T) {
+	t.Parallel()
+	if _, err := MarshalSpec(Not(customSpec{}), jsonOpts); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+}
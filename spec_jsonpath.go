@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	jsonPathEq  = "path_eq"
+	jsonHasPath = "has_path"
+)
+
+type jsonPathSpec struct {
+	column string
+	op     string
+	path   string
+	value  any
+}
+
+func JSONPathEq(column, path string, value any) Spec {
+	return &jsonPathSpec{column: column, op: jsonPathEq, path: path, value: value}
+}
+
+func JSONHasKey(column, key string) Spec {
+	return &jsonPathSpec{column: column, op: jsonHasPath, path: formatJSONPath([]jsonPathSegment{{key: key}})}
+}
+
+func JSONHasPath(column, path string) Spec {
+	return &jsonPathSpec{column: column, op: jsonHasPath, path: path}
+}
+
+func (s *jsonPathSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
+	segs, err := parseJSONPath(s.path)
+	if err != nil {
+		return "FALSE", nil, offset
+	}
+	var args []any
+	bind := binder(d, offset, &args)
+	col := quoteIdent(d, s.column)
+	var sql string
+	if s.op == jsonHasPath {
+		sql = jsonHasPathSQL(d, col, formatJSONPath(segs), bind)
+	} else {
+		sql = jsonPathEqSQL(d, col, formatJSONPath(segs), s.value, bind)
+	}
+	if sql == "" {
+		return "FALSE", nil, offset
+	}
+	return sql, args, offset + len(args)
+}
+
+func (s *jsonPathSpec) validate() error {
+	if _, err := parseJSONPath(s.path); err != nil {
+		return err
+	}
+	if s.op == jsonPathEq {
+		if _, err := json.Marshal(s.value); err != nil {
+			return fmt.Errorf("%w: JSON value: %v", ErrInvalidSpec, err)
+		}
+	}
+	return nil
+}
+
+func (s *jsonPathSpec) checkDialect(d Dialect) error {
+	bind := func(any) string { return "?" }
+	sql := jsonPathEqSQL(d, s.column, s.path, s.value, bind)
+	if s.op == jsonHasPath {
+		sql = jsonHasPathSQL(d, s.column, s.path, bind)
+	}
+	if sql == "" {
+		return fmt.Errorf("%w: JSON %s is not supported by %T", ErrUnsupportedSpec, s.op, d)
+	}
+	return nil
+}
+
+func (s *jsonPathSpec) inspect() Node {
+	values := []any{s.path}
+	if s.op == jsonPathEq {
+		values = append(values, s.value)
+	}
+	return Node{Kind: JSONNode, Op: s.op, Column: s.column, Values: values}
+}
+
+func (s *jsonPathSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	col, err := fn(s.column)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonPathSpec{column: col, op: s.op, path: s.path, value: s.value}, nil
+}
+
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	invalid := func() ([]jsonPathSegment, error) {
+		return nil, fmt.Errorf("%w: JSON path %q", ErrInvalidSpec, path)
+	}
+	if !strings.HasPrefix(path, "$") || len(path) == 1 {
+		return invalid()
+	}
+	var segs []jsonPathSegment
+	for rest := path[1:]; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, `."`):
+			end := strings.IndexByte(rest[2:], '"')
+			if end <= 0 {
+				return invalid()
+			}
+			segs = append(segs, jsonPathSegment{key: rest[2 : 2+end]})
+			rest = rest[3+end:]
+		case rest[0] == '.':
+			end := 1
+			for end < len(rest) && (isIdentStart(rest[end]) || end > 1 && isDigit(rest[end])) {
+				end++
+			}
+			if end == 1 {
+				return invalid()
+			}
+			segs = append(segs, jsonPathSegment{key: rest[1:end]})
+			rest = rest[end:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return invalid()
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil || n < 0 {
+				return invalid()
+			}
+			segs = append(segs, jsonPathSegment{index: n, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return invalid()
+		}
+	}
+	return segs, nil
+}
+
+func bindJSONPath(path string, bind func(any) string) string {
+	segs, _ := parseJSONPath(path)
+	parts := make([]string, len(segs))
+	for i, s := range segs {
+		if s.isIndex {
+			parts[i] = bind(strconv.Itoa(s.index))
+		} else {
+			parts[i] = bind(s.key)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatJSONPath(segs []jsonPathSegment) string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, s := range segs {
+		switch {
+		case s.isIndex:
+			b.WriteString("[" + strconv.Itoa(s.index) + "]")
+		case isIdent(s.key):
+			b.WriteString("." + s.key)
+		default:
+			b.WriteString(`."` + s.key + `"`)
+		}
+	}
+	return b.String()
+}
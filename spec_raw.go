@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type rawSpec struct {
+	sql      string
+	args     []any
+	names    []string
+	parts    []rawPart
+	err      error
+	escParts []rawPart
+	escErr   error
+}
+
+type rawPart struct {
+	text string
+	arg  int
+}
+
+func Raw(sql string, args ...any) Spec {
+	s := &rawSpec{sql: sql, args: args}
+	s.parts, s.err = parseRaw(sql, len(args), false)
+	s.escParts, s.escErr = parseRaw(sql, len(args), true)
+	return s
+}
+
+func RawNamed(sql string, params map[string]any) Spec {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	args := make([]any, len(names))
+	for i, name := range names {
+		args[i] = params[name]
+	}
+	s := &rawSpec{sql: sql, args: args, names: names}
+	s.parts, s.err = parseRawNamed(sql, names, false)
+	s.escParts, s.escErr = parseRawNamed(sql, names, true)
+	return s
+}
+
+func (s *rawSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
+	parts, err := s.partsFor(d)
+	if err != nil {
+		return invalidSQL("raw spec"), nil, offset
+	}
+	var b strings.Builder
+	var args []any
+	current := offset
+	for _, p := range parts {
+		b.WriteString(p.text)
+		if p.arg < 0 {
+			continue
+		}
+		if sub, ok := s.args[p.arg].(Spec); ok && sub != nil {
+			sql, subArgs, next := sub.ToSQL(d, current)
+			b.WriteString(sql)
+			args = append(args, subArgs...)
+			current = next
+			continue
+		}
+		b.WriteString(d.Placeholder(current))
+		args = append(args, s.args[p.arg])
+		current++
+	}
+	return b.String(), args, current
+}
+
+func (s *rawSpec) validate() error {
+	if s.err != nil && s.escErr != nil {
+		return fmt.Errorf("%w: raw: %v", ErrInvalidSpec, s.err)
+	}
+	return nil
+}
+
+func (s *rawSpec) checkDialect(d Dialect) error {
+	if _, err := s.partsFor(d); err != nil {
+		return fmt.Errorf("%w: raw: %v", ErrInvalidSpec, err)
+	}
+	return nil
+}
+
+func (s *rawSpec) partsFor(d Dialect) ([]rawPart, error) {
+	if backslashEscapes(d) {
+		return s.escParts, s.escErr
+	}
+	return s.parts, s.err
+}
+
+func (s *rawSpec) params() map[string]any {
+	params := make(map[string]any, len(s.names))
+	for i, name := range s.names {
+		params[name] = s.args[i]
+	}
+	return params
+}
+
+func (s *rawSpec) subSpecs() []Spec {
+	var specs []Spec
+	for _, a := range s.args {
+		if sub, ok := a.(Spec); ok && sub != nil {
+			specs = append(specs, sub)
+		}
+	}
+	return specs
+}
+
+type rawToken struct {
+	text string
+	kind byte
+	num  int
+	name string
+}
+
+const (
+	rawText       byte = 0
+	rawDollar     byte = '$'
+	rawQuestion   byte = '?'
+	rawEscapedQ   byte = 'q'
+	rawNamedParam byte = ':'
+)
+
+func parseRaw(sql string, argCount int, backslash bool) ([]rawPart, error) {
+	tokens := tokenizeRaw(sql, false, backslash)
+	dollar := false
+	for _, t := range tokens {
+		if t.kind == rawDollar {
+			dollar = true
+			break
+		}
+	}
+
+	parts := make([]rawPart, 0, len(tokens))
+	used := make([]bool, argCount)
+	next := 0
+	for _, t := range tokens {
+		switch {
+		case t.kind == rawDollar:
+			if t.num < 1 || t.num > argCount {
+				return nil, fmt.Errorf("placeholder $%d has no argument (%d given)", t.num, argCount)
+			}
+			used[t.num-1] = true
+			parts = append(parts, rawPart{arg: t.num - 1})
+		case t.kind == rawQuestion && !dollar && argCount > 0:
+			if next >= argCount {
+				return nil, fmt.Errorf("placeholder ? #%d has no argument (%d given), write ?? for a literal ?",
+					next+1, argCount)
+			}
+			parts = append(parts, rawPart{arg: next})
+			next++
+		case t.kind == rawEscapedQ && !dollar:
+			parts = append(parts, rawPart{text: "?", arg: -1})
+		default:
+			parts = append(parts, rawPart{text: t.text, arg: -1})
+		}
+	}
+	if !dollar && next < argCount {
+		return nil, fmt.Errorf("%d argument(s) given, %d placeholder(s) ?", argCount, next)
+	}
+	var unused []string
+	for i, ok := range used {
+		if dollar && !ok {
+			unused = append(unused, "$"+strconv.Itoa(i+1))
+		}
+	}
+	if len(unused) > 0 {
+		return nil, fmt.Errorf("unused argument(s) %s", strings.Join(unused, ", "))
+	}
+	return parts, nil
+}
+
+func parseRawNamed(sql string, names []string, backslash bool) ([]rawPart, error) {
+	tokens := tokenizeRaw(sql, true, backslash)
+	parts := make([]rawPart, 0, len(tokens))
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+	used := make([]bool, len(names))
+	var missing []string
+	for _, t := range tokens {
+		if t.kind != rawNamedParam {
+			parts = append(parts, rawPart{text: t.text, arg: -1})
+			continue
+		}
+		i, ok := index[t.name]
+		if !ok {
+			if !slices.Contains(missing, t.name) {
+				missing = append(missing, t.name)
+			}
+			continue
+		}
+		used[i] = true
+		parts = append(parts, rawPart{arg: i})
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing parameter(s) %s", strings.Join(missing, ", "))
+	}
+	var unused []string
+	for i, ok := range used {
+		if !ok {
+			unused = append(unused, names[i])
+		}
+	}
+	if len(unused) > 0 {
+		return nil, fmt.Errorf("unused parameter(s) %s", strings.Join(unused, ", "))
+	}
+	return parts, nil
+}
+
+func tokenizeRaw(sql string, named, backslash bool) []rawToken {
+	var tokens []rawToken
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			tokens = append(tokens, rawToken{text: sql[start:end]})
+		}
+	}
+	emit := func(i, end int, t rawToken) {
+		flush(i)
+		t.text = sql[i:end]
+		tokens = append(tokens, t)
+		start = end
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i, c, backslash && c != '`' || c == '\'' && escapeStringPrefix(sql, i))
+		case strings.HasPrefix(sql[i:], "--"):
+			i = skipUntil(sql, i+2, "\n")
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = skipUntil(sql, i+2, "*/")
+		case c == '$' && !named && i+1 < len(sql) && isDigit(sql[i+1]):
+			end := i + 1
+			for end < len(sql) && isDigit(sql[end]) {
+				end++
+			}
+			n, _ := strconv.Atoi(sql[i+1 : end])
+			emit(i, end, rawToken{kind: rawDollar, num: n})
+			i = end
+		case c == '$':
+			i = skipDollarQuoted(sql, i)
+		case c == '?' && !named:
+			if i+1 < len(sql) && sql[i+1] == '?' {
+				emit(i, i+2, rawToken{kind: rawEscapedQ})
+				i += 2
+				continue
+			}
+			emit(i, i+1, rawToken{kind: rawQuestion})
+			i++
+		case c == ':' && named:
+			if i+1 < len(sql) && sql[i+1] == ':' {
+				i += 2
+				continue
+			}
+			end := i + 1
+			for end < len(sql) && (isIdentStart(sql[end]) || end > i+1 && isDigit(sql[end])) {
+				end++
+			}
+			if end == i+1 {
+				i++
+				continue
+			}
+			emit(i, end, rawToken{kind: rawNamedParam, name: sql[i+1 : end]})
+			i = end
+		default:
+			i++
+		}
+	}
+	flush(len(sql))
+	return tokens
+}
+
+func skipQuoted(sql string, i int, quote byte, backslash bool) int {
+	for j := i + 1; j < len(sql); j++ {
+		if sql[j] == '\\' && backslash {
+			j++
+			continue
+		}
+		if sql[j] != quote {
+			continue
+		}
+		if j+1 < len(sql) && sql[j+1] == quote {
+			j++
+			continue
+		}
+		return j + 1
+	}
+	return len(sql)
+}
+
+func escapeStringPrefix(sql string, i int) bool {
+	if i == 0 || sql[i-1] != 'E' && sql[i-1] != 'e' {
+		return false
+	}
+	return i == 1 || !isIdentStart(sql[i-2]) && !isDigit(sql[i-2])
+}
+
+func skipUntil(sql string, i int, end string) int {
+	if k := strings.Index(sql[i:], end); k >= 0 {
+		return i + k + len(end)
+	}
+	return len(sql)
+}
+
+func skipDollarQuoted(sql string, i int) int {
+	j := i + 1
+	for j < len(sql) && (isIdentStart(sql[j]) || j > i+1 && isDigit(sql[j])) {
+		j++
+	}
+	if j >= len(sql) || sql[j] != '$' {
+		return i + 1
+	}
+	tag := sql[i : j+1]
+	return skipUntil(sql, j+1, tag)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRaw_Tokenizer(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name     string
+		spec     Spec
+		wantSQL  string
+		wantArgs []any
+	}{
+		{"literal with placeholder", Raw("note = '$1 off' AND x > $1", 5), "note = '$1 off' AND x > $2", []any{5}},
+		{"comments", Raw("x > $1 -- $2\n/* $2 */ AND y = $1", 5), "x > $2 -- $2\n/* $2 */ AND y = $3", []any{5, 5}},
+		{"question marks", Raw("a = ? AND b = ?", 1, 2), "a = $2 AND b = $3", []any{1, 2}},
+		{"escaped question", Raw("data ?? 'k' AND a = ?", 1), "data ? 'k' AND a = $2", []any{1}},
+		{"jsonb with dollar", Raw("data ? 'k' AND a = $1", 1), "data ? 'k' AND a = $2", []any{1}},
+		{"dollar quoted", Raw("x = $$it's $1$$ AND y = $1", 1), "x = $$it's $1$$ AND y = $2", []any{1}},
+		{"quoted identifier", Raw(`"a?b" = ?`, 1), `"a?b" = $2`, []any{1}},
+		{"standard backslash", Raw(`note = 'a\' AND x > $1`, 5), `note = 'a\' AND x > $2`, []any{5}},
+		{"escape string", Raw(`note = E'a\'b ?' AND x = ?`, 5), `note = E'a\'b ?' AND x = $2`, []any{5}},
+		{"operator without args", Raw("data ? 'k'"), "data ? 'k'", nil},
+		{"operator after args", Raw("a = ? AND data ?? 'k'", 1), "a = $2 AND data ? 'k'", []any{1}},
+		{"two digit", Raw("$10 + $1 + $2 + $3 + $4 + $5 + $6 + $7 + $8 + $9", 1, 2, 3, 4, 5, 6, 7, 8, 9, 10),
+			"$2 + $3 + $4 + $5 + $6 + $7 + $8 + $9 + $10 + $11", []any{10, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+	}
+	for _, tc := range cases {
+		sql, args, next := tc.spec.ToSQL(pgDialect(), 2)
+		if sql != tc.wantSQL || !reflect.DeepEqual(args, tc.wantArgs) || next != 2+len(tc.wantArgs) {
+			t.Errorf("%s: got %q %v %d", tc.name, sql, args, next)
+		}
+	}
+}
+
+func TestRaw_MySQLRepeatedPlaceholder(t *testing.T) {
+	t.Parallel()
+	sql, args, _ := Raw("a = $1 OR b = $1", 7).ToSQL(MySQL(), 1)
+	if sql != "a = ? OR b = ?" || !reflect.DeepEqual(args, []any{7, 7}) {
+		t.Errorf("got %q %v", sql, args)
+	}
+}
+
+func TestRaw_MySQLBackslashEscapes(t *testing.T) {
+	t.Parallel()
+	spec := Raw(`note = 'it\'s ?' AND x = ?`, 5)
+	sql, args, _ := spec.ToSQL(MySQL(), 1)
+	if sql != `note = 'it\'s ?' AND x = ?` || !reflect.DeepEqual(args, []any{5}) {
+		t.Errorf("got %q %v", sql, args)
+	}
+	named := RawNamed(`note = 'it\'s :x' AND y = :y`, map[string]any{"y": 1})
+	if sql, args, _ := named.ToSQL(MySQL(), 1); sql != `note = 'it\'s :x' AND y = ?` || !reflect.DeepEqual(args, []any{1}) {
+		t.Errorf("got %q %v", sql, args)
+	}
+	if err := validateDialect(named, MySQL()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateDialect(named, pgDialect()); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("standard strings end at \\', expected ErrInvalidSpec, got %v", err)
+	}
+}
+
+func TestRawNamed(t *testing.T) {
+	t.Parallel()
+	spec := RawNamed("amount > :min AND region = :region AND x::text <> ':min' AND total > :min",
+		map[string]any{"min": 100, "region": "eu"})
+	sql, args, next := spec.ToSQL(pgDialect(), 1)
+	if sql != "amount > $1 AND region = $2 AND x::text <> ':min' AND total > $3" {
+		t.Errorf("unexpected SQL: %s", sql)
+	}
+	if !reflect.DeepEqual(args, []any{100, "eu", 100}) || next != 4 {
+		t.Errorf("unexpected args %v next %d", args, next)
+	}
+	if err := Validate(spec); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRaw_EmbeddedSpec(t *testing.T) {
+	t.Parallel()
+	sub := And(Eq("o.user_id", 1), Gt("o.total", 10))
+	spec := And(Eq("active", true), Raw("EXISTS (SELECT 1 FROM orders o WHERE ? AND o.kind = ?)", sub, "web"))
+	sql, args, next := spec.ToSQL(pgDialect(), 1)
+	want := `("active" = $1) AND (EXISTS (SELECT 1 FROM orders o WHERE ("o"."user_id" = $2) AND ("o"."total" > $3) AND o.kind = $4))`
+	if sql != want || !reflect.DeepEqual(args, []any{true, 1, 10, "web"}) || next != 5 {
+		t.Errorf("got %s %v %d", sql, args, next)
+	}
+
+	named := RawNamed("NOT (:cond)", map[string]any{"cond": Eq("a", 1)})
+	if got := Columns(named); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("embedded spec columns should be visible, got %v", got)
+	}
+	renamed, err := RenameColumns(named, map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql, _, _ := renamed.ToSQL(pgDialect(), 1); sql != `NOT ("b" = $1)` {
+		t.Errorf("unexpected renamed SQL: %s", sql)
+	}
+}
+
+func TestRaw_Validate(t *testing.T) {
+	t.Parallel()
+	invalid := []Spec{
+		Raw("a = $2", 1),
+		Raw("a = ?", 1, 2),
+		Raw("a = ? AND b = ?", 1),
+		Raw("a = $1", 1, 2, 3),
+		RawNamed("a = :a", map[string]any{}),
+		RawNamed("a = :a", map[string]any{"a": 1, "b": 2}),
+		And(Eq("x", 1), Not(Raw("y = $3"))),
+	}
+	for _, s := range invalid {
+		if err := Validate(s); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("expected ErrInvalidSpec, got %v", err)
+		}
+	}
+	if sql, args, next := And(Eq("x", 1), Raw("y = $3")).ToSQL(pgDialect(), 1); sql != `("x" = $1) AND (<invalid raw spec>)` ||
+		len(args) != 1 || next != 2 {
+		t.Errorf("invalid raw spec must render failing SQL, got %q %v %d", sql, args, next)
+	}
+	conn := &testConn{}
+	repo := newSimpleTestRepo(t, conn, simpleTable)
+	if _, err := repo.FindBy(t.Context(), Raw("id = $2", 1)); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("repository should reject invalid raw spec, got %v", err)
+	}
+}
+
+func TestRawNamed_JSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	opts := SpecJSONOptions{AllowRaw: true}
+	data, err := MarshalSpec(RawNamed("a > :min", map[string]any{"min": 5}), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"rawnamed":["a \u003e :min",{"min":5}]}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+	spec, err := UnmarshalSpec(data, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sql, args, _ := spec.ToSQL(pgDialect(), 1); sql != "a > $1" || args[0] != int64(5) {
+		t.Errorf("got %s %v", sql, args)
+	}
+	if _, err := MarshalSpec(Raw("?", Eq("a", 1)), opts); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+	if _, err := UnmarshalSpec([]byte(`{"raw":["a = $2", 1]}`), opts); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec, got %v", err)
+	}
+}
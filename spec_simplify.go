@@ -0,0 +1,104 @@
+package repository
+
+import "reflect"
+
+func Simplify(s Spec) Spec {
+	switch sp := s.(type) {
+	case *andSpec:
+		return simplifyJunction(sp.specs, true)
+	case *orSpec:
+		return simplifyJunction(sp.specs, false)
+	case *notSpec:
+		inner := Simplify(sp.spec)
+		switch {
+		case isConst(inner, true):
+			return Or()
+		case isConst(inner, false):
+			return And()
+		}
+		if n, ok := inner.(*notSpec); ok {
+			return n.spec
+		}
+		return &notSpec{spec: inner}
+	case *inSpec:
+		if len(sp.values) == 0 {
+			if sp.negate {
+				return And()
+			}
+			return Or()
+		}
+		c := *sp
+		c.values = dedupeValues(sp.values)
+		return &c
+	case *foldSpec:
+		if len(sp.values) == 0 {
+			return Or()
+		}
+	case *arraySpec:
+		if len(sp.values) == 0 {
+			if sp.op == arrayContains {
+				return And()
+			}
+			return Or()
+		}
+	}
+	return s
+}
+
+func simplifyJunction(specs []Spec, and bool) Spec {
+	flat := make([]Spec, 0, len(specs))
+	for _, child := range specs {
+		if child == nil {
+			continue
+		}
+		child = Simplify(child)
+		switch {
+		case isConst(child, and):
+			continue
+		case isConst(child, !and):
+			return child
+		}
+		if and {
+			if nested, ok := child.(*andSpec); ok {
+				flat = append(flat, nested.specs...)
+				continue
+			}
+		} else if nested, ok := child.(*orSpec); ok {
+			flat = append(flat, nested.specs...)
+			continue
+		}
+		flat = append(flat, child)
+	}
+	if len(flat) == 1 {
+		return flat[0]
+	}
+	if and {
+		return &andSpec{specs: flat}
+	}
+	return &orSpec{specs: flat}
+}
+
+func isConst(s Spec, value bool) bool {
+	switch sp := s.(type) {
+	case *andSpec:
+		return value && len(sp.specs) == 0
+	case *orSpec:
+		return !value && len(sp.specs) == 0
+	}
+	return false
+}
+
+func dedupeValues(values []any) []any {
+	result := make([]any, 0, len(values))
+	seen := make(map[any]bool, len(values))
+	for _, v := range values {
+		if v == nil || reflect.ValueOf(v).Comparable() {
+			if seen[v] {
+				continue
+			}
+			seen[v] = true
+		}
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	t.Parallel()
+	a, b, c := Eq("a", 1), Eq("b", 2), Eq("c", 3)
+	cases := []struct {
+		name string
+		in   Spec
+		want string
+	}{
+		{"flatten and", And(And(a, b), c), `("a" = $1) AND ("b" = $2) AND ("c" = $3)`},
+		{"flatten or", Or(a, Or(b, Or(c))), `("a" = $1) OR ("b" = $2) OR ("c" = $3)`},
+		{"single child", And(And(a)), `"a" = $1`},
+		{"and identity", And(a, And(), NotIn("x")), `"a" = $1`},
+		{"or identity", Or(Or(), a, In("x")), `"a" = $1`},
+		{"and absorbing", And(a, In("x")), `FALSE`},
+		{"or absorbing", Or(a, And()), `TRUE`},
+		{"empty in", In("x"), `FALSE`},
+		{"empty not in", NotIn("x"), `TRUE`},
+		{"double negation", Not(Not(a)), `"a" = $1`},
+		{"triple negation", Not(Not(Not(a))), `NOT ("a" = $1)`},
+		{"not true", Not(And()), `FALSE`},
+		{"not false", Not(In("x")), `TRUE`},
+		{"dedupe in", In("x", 1, 2, 1, "1", nil, nil), `"x" IN ($1, $2, $3, $4)`},
+		{"nested noise", And(And(a), Or(), b), `FALSE`},
+		{"raw untouched", And(Raw("x = $1", 1), And(b)), `(x = $1) AND ("b" = $2)`},
+	}
+	for _, tc := range cases {
+		sql, _, _ := Simplify(tc.in).ToSQL(pgDialect(), 1)
+		if sql != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, sql)
+		}
+	}
+}
+
+func TestSimplify_DedupeKeepsOrderAndArgs(t *testing.T) {
+	t.Parallel()
+	s := Simplify(In("x", 3, []byte("a"), 3, []byte("a"), 1, int64(1)))
+	_, args, next := s.ToSQL(pgDialect(), 5)
+	if !reflect.DeepEqual(args, []any{3, []byte("a"), []byte("a"), 1, int64(1)}) || next != 10 {
+		t.Errorf("unexpected args %v, next %d", args, next)
+	}
+
+	type key struct{ v any }
+	s = Simplify(In("x", key{[]int{1}}, key{[]int{1}}, key{1}, key{1}))
+	if _, args, _ := s.ToSQL(pgDialect(), 1); len(args) != 3 {
+		t.Errorf("only comparable values should be deduped, got %v", args)
+	}
+}
+
+func TestJoinSpecs_AppliesSimplify(t *testing.T) {
+	t.Parallel()
+	spec := And(And(Eq("a", 1), In("b", 2, 2)), Or(), Not(Not(Eq("c", 3))))
+	spec = Or(spec, Eq("d", 4))
+	sql, args, next := spec.ToSQL(pgDialect(), 1)
+	if sql != `"d" = $1` || len(args) != 1 || next != 2 {
+		t.Errorf("unexpected: %s %v %d", sql, args, next)
+	}
+
+	sql, args, next = And(Eq("a", 1), And(In("b", 2, 2), Not(Not(Eq("c", 3))))).ToSQL(pgDialect(), 1)
+	if sql != `("a" = $1) AND ("b" IN ($2)) AND ("c" = $3)` || len(args) != 3 || next != 4 {
+		t.Errorf("unexpected: %s %v %d", sql, args, next)
+	}
+}
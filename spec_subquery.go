@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+type existsSpec struct {
+	table    string
+	where    Spec
+	innerCol string
+	outerCol string
+}
+
+func Exists(table string, where Spec) Spec {
+	return &existsSpec{table: table, where: where}
+}
+
+func (s *existsSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
+	var conds []string
+	if s.innerCol != "" {
+		conds = append(conds, fmt.Sprintf("%s = %s",
+			quoteIdent(d, s.table+"."+s.innerCol), quoteIdent(d, s.outerCol)))
+	}
+	var args []any
+	next := offset
+	if s.where != nil {
+		var sql string
+		sql, args, next = s.where.ToSQL(d, offset)
+		if len(conds) > 0 {
+			sql = "(" + sql + ")"
+		}
+		conds = append(conds, sql)
+	}
+	query := "SELECT 1 FROM " + quoteIdent(d, s.table)
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return "EXISTS (" + query + ")", args, next
+}
+
+type inSelectSpec struct {
+	column       string
+	table        string
+	selectColumn string
+	where        Spec
+}
+
+func InSelect(column, table, selectColumn string, where Spec) Spec {
+	return &inSelectSpec{column: column, table: table, selectColumn: selectColumn, where: where}
+}
+
+func (s *inSelectSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
+	query := fmt.Sprintf("SELECT %s FROM %s", quoteIdent(d, s.selectColumn), quoteIdent(d, s.table))
+	var args []any
+	next := offset
+	if s.where != nil {
+		var sql string
+		sql, args, next = s.where.ToSQL(d, offset)
+		query += " WHERE " + sql
+	}
+	return fmt.Sprintf("%s IN (%s)", quoteIdent(d, s.column), query), args, next
+}
+
+type hasChildSpec struct {
+	relation string
+	where    Spec
+}
+
+func HasChild(relationTable string, where Spec) Spec {
+	return &hasChildSpec{relation: relationTable, where: where}
+}
+
+func (s *hasChildSpec) ToSQL(_ Dialect, offset int) (string, []any, int) {
+	return invalidSQL("unbound HasChild"), nil, offset
+}
+
+func (s *hasChildSpec) validate() error {
+	return fmt.Errorf("%w: HasChild(%q) is not bound to a repository with that relation", ErrInvalidSpec, s.relation)
+}
+
+func (s *hasChildSpec) bind(root Table, relations []Relation) (Spec, error) {
+	for _, rel := range relations {
+		if rel.Table != s.relation {
+			continue
+		}
+		if len(root.PrimaryKey) != 1 {
+			return nil, fmt.Errorf("%w: HasChild(%q) needs a single-column primary key, root table has %d",
+				ErrInvalidSpec, s.relation, len(root.PrimaryKey))
+		}
+		return &existsSpec{
+			table:    rel.Table,
+			where:    s.where,
+			innerCol: rel.ForeignKey,
+			outerCol: root.Name + "." + root.PrimaryKey[0],
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown relation %q", ErrInvalidSpec, s.relation)
+}
+
+func bindRelations(s Spec, root Table, relations []Relation) (Spec, error) {
+	return transformSpec(s, func(n Spec) (Spec, error) {
+		if h, ok := n.(*hasChildSpec); ok {
+			return h.bind(root, relations)
+		}
+		return n, nil
+	})
+}
+
+func (s *existsSpec) inspect() Node {
+	return Node{Kind: ExistsNode, Op: "EXISTS", Table: s.table, Children: specList(s.where)}
+}
+
+func (s *existsSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	where, err := mapSubquery(s.where, fn)
+	if err != nil {
+		return nil, err
+	}
+	copy := *s
+	copy.where = where
+	return &copy, nil
+}
+
+func (s *inSelectSpec) inspect() Node {
+	return Node{Kind: InSelectNode, Op: "IN", Column: s.column, Table: s.table,
+		SelectColumn: s.selectColumn, Children: specList(s.where)}
+}
+
+func (s *inSelectSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	col, err := fn(s.column)
+	if err != nil {
+		return nil, err
+	}
+	where, err := mapSubquery(s.where, fn)
+	if err != nil {
+		return nil, err
+	}
+	copy := *s
+	copy.column, copy.where = col, where
+	return &copy, nil
+}
+
+func (s *hasChildSpec) inspect() Node {
+	return Node{Kind: HasChildNode, Op: "EXISTS", Table: s.relation, Children: specList(s.where)}
+}
+
+func (s *hasChildSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	where, err := mapSubquery(s.where, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &hasChildSpec{relation: s.relation, where: where}, nil
+}
+
+func mapSubquery(s Spec, fn func(string) (string, error)) (Spec, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return MapColumns(s, fn)
+}
+
+type outerSpec struct{ Spec }
+
+func (s *outerSpec) inspect() Node { return Inspect(s.Spec) }
+
+func (s *outerSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	in, ok := s.Spec.(*inSelectSpec)
+	if !ok {
+		return s, nil
+	}
+	col, err := fn(in.column)
+	if err != nil {
+		return nil, err
+	}
+	copy := *in
+	copy.column = col
+	return &outerSpec{Spec: &copy}, nil
+}
+
+func mapOuterColumns(s Spec, fn func(string) (string, error)) (Spec, error) {
+	if s == nil {
+		return nil, nil
+	}
+	hidden, err := transformSpec(s, func(n Spec) (Spec, error) {
+		switch n.(type) {
+		case *existsSpec, *inSelectSpec, *hasChildSpec:
+			return &outerSpec{Spec: n}, nil
+		}
+		return n, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	mapped, err := MapColumns(hidden, fn)
+	if err != nil {
+		return nil, err
+	}
+	return transformSpec(mapped, func(n Spec) (Spec, error) {
+		if o, ok := n.(*outerSpec); ok {
+			return o.Spec, nil
+		}
+		return n, nil
+	})
+}
+
+func specList(s Spec) []Spec {
+	if s == nil {
+		return nil
+	}
+	return []Spec{s}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExists_ToSQL(t *testing.T) {
+	t.Parallel()
+	spec := And(Eq("status", "paid"), Exists("items", And(Eq("sku", "X"), Gt("qty", 1))))
+	sql, args, next := spec.ToSQL(pgDialect(), 1)
+	want := `("status" = $1) AND (EXISTS (SELECT 1 FROM "items" WHERE ("sku" = $2) AND ("qty" > $3)))`
+	if sql != want || !reflect.DeepEqual(args, []any{"paid", "X", 1}) || next != 4 {
+		t.Errorf("got %s %v %d", sql, args, next)
+	}
+	if sql, _, _ := Exists("items", nil).ToSQL(MySQL(), 1); sql != "EXISTS (SELECT 1 FROM `items`)" {
+		t.Errorf("got %s", sql)
+	}
+}
+
+func TestInSelect_ToSQL(t *testing.T) {
+	t.Parallel()
+	spec := Or(Eq("vip", true), InSelect("id", "orders", "user_id", Gte("total", 100)))
+	sql, args, next := spec.ToSQL(pgDialect(), 3)
+	want := `("vip" = $3) OR ("id" IN (SELECT "user_id" FROM "orders" WHERE "total" >= $4))`
+	if sql != want || !reflect.DeepEqual(args, []any{true, 100}) || next != 5 {
+		t.Errorf("got %s %v %d", sql, args, next)
+	}
+	if got := Columns(spec); !reflect.DeepEqual(got, []string{"vip", "id"}) {
+		t.Errorf("subquery columns must not leak into outer columns, got %v", got)
+	}
+}
+
+func TestHasChild_BoundByRepository(t *testing.T) {
+	t.Parallel()
+	r := &Repository[string]{table: compositeTable, dialect: Postgres(), relations: []Relation{itemsRelation}}
+	spec, err := r.prepareSpec(And(Eq("name", "a"), Not(HasChild("items", Eq("value", "x")))))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sql, args, _ := spec.ToSQL(pgDialect(), 1)
+	want := `("name" = $1) AND (NOT (EXISTS (SELECT 1 FROM "items" WHERE "items"."order_id" = "orders"."id" AND ("value" = $2))))`
+	if sql != want || !reflect.DeepEqual(args, []any{"a", "x"}) {
+		t.Errorf("got %s %v", sql, args)
+	}
+
+	if _, err := r.prepareSpec(HasChild("payments", nil)); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec for unknown relation, got %v", err)
+	}
+	if sql, _, _ := Not(HasChild("items", nil)).ToSQL(pgDialect(), 1); sql != "NOT (<invalid unbound HasChild>)" {
+		t.Errorf("unbound HasChild should render failing SQL, got %s", sql)
+	}
+
+	composite := compositeTable
+	composite.PrimaryKey = []string{"id", "tenant_id"}
+	r = &Repository[string]{table: composite, dialect: Postgres(), relations: []Relation{itemsRelation}}
+	if _, err := r.prepareSpec(HasChild("items", nil)); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec for composite primary key, got %v", err)
+	}
+	if err := Validate(HasChild("items", nil)); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("unbound HasChild should fail validation, got %v", err)
+	}
+}
+
+func TestHasChild_Memory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := NewMemory(Composite(CompositeConfig[string, *tSnap]{
+		Table:     compositeTable,
+		Relations: []Relation{itemsRelation},
+		ScanRoot:  compositeScanRoot,
+		ScanChild: compositeScanChild,
+		Build:     func(s *tSnap) (string, error) { return s.id, nil },
+		Decompose: func(s string) CompositeValues {
+			return CompositeValues{
+				Root:     []any{s, "name"},
+				Children: map[string][][]any{"items": {{s + "-1", s, "sku-" + s}}},
+			}
+		},
+		ExtractPK: compositeExtractPK,
+	}))
+	_ = m.Save(ctx, "a")
+	_ = m.Save(ctx, "b")
+
+	found, err := m.FindBy(ctx, HasChild("items", Eq("value", "sku-b")))
+	if err != nil || !reflect.DeepEqual(found, []string{"b"}) {
+		t.Errorf("unexpected: %v, %v", found, err)
+	}
+	n, err := m.Query(ctx).Where(Not(HasChild("items", nil))).Count()
+	if err != nil || n != 0 {
+		t.Errorf("unexpected: %d, %v", n, err)
+	}
+	if _, err := m.FindBy(ctx, HasChild("payments", nil)); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec, got %v", err)
+	}
+}
@@ -9,7 +9,7 @@ func pgDialect() Dialect { return Postgres() }
 func TestEq_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, args, next := Eq("name", "alice").ToSQL(pgDialect(), 1)
-	if sql != "name = $1" {
+	if sql != `"name" = $1` {
 		t.Errorf("expected 'name = $1', got %q", sql)
 	}
 	if len(args) != 1 || args[0] != "alice" {
@@ -23,7 +23,7 @@ func TestEq_ToSQL(t *testing.T) {
 func TestNotEq_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, _, _ := NotEq("x", 1).ToSQL(pgDialect(), 3)
-	if sql != "x != $3" {
+	if sql != `"x" != $3` {
 		t.Errorf("expected 'x != $3', got %q", sql)
 	}
 }
@@ -31,7 +31,7 @@ func TestNotEq_ToSQL(t *testing.T) {
 func TestGt_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, _, _ := Gt("x", 5).ToSQL(pgDialect(), 1)
-	if sql != "x > $1" {
+	if sql != `"x" > $1` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -39,7 +39,7 @@ func TestGt_ToSQL(t *testing.T) {
 func TestGte_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, _, _ := Gte("x", 5).ToSQL(pgDialect(), 1)
-	if sql != "x >= $1" {
+	if sql != `"x" >= $1` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -47,7 +47,7 @@ func TestGte_ToSQL(t *testing.T) {
 func TestLt_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, _, _ := Lt("x", 5).ToSQL(pgDialect(), 1)
-	if sql != "x < $1" {
+	if sql != `"x" < $1` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -55,7 +55,7 @@ func TestLt_ToSQL(t *testing.T) {
 func TestLte_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, _, _ := Lte("x", 5).ToSQL(pgDialect(), 1)
-	if sql != "x <= $1" {
+	if sql != `"x" <= $1` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -68,7 +68,7 @@ func TestIn_ToSQL(t *testing.T) {
 		wantSQL string
 		wantN   int
 	}{
-		{"with values", In("id", 1, 2, 3), "id IN ($1, $2, $3)", 3},
+		{"with values", In("id", 1, 2, 3), `"id" IN ($1, $2, $3)`, 3},
 		{"empty", In("id"), "FALSE", 0},
 	}
 	for _, tt := range tests {
@@ -92,7 +92,7 @@ func TestNotIn_ToSQL(t *testing.T) {
 		spec    Spec
 		wantSQL string
 	}{
-		{"with values", NotIn("id", 1, 2), "id NOT IN ($1, $2)"},
+		{"with values", NotIn("id", 1, 2), `"id" NOT IN ($1, $2)`},
 		{"empty", NotIn("id"), "TRUE"},
 	}
 	for _, tt := range tests {
@@ -109,7 +109,7 @@ func TestNotIn_ToSQL(t *testing.T) {
 func TestLike_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, args, _ := Like("name", "%test%").ToSQL(pgDialect(), 1)
-	if sql != "name LIKE $1" {
+	if sql != `"name" LIKE $1` {
 		t.Errorf("got %q", sql)
 	}
 	if len(args) != 1 || args[0] != "%test%" {
@@ -120,7 +120,7 @@ func TestLike_ToSQL(t *testing.T) {
 func TestILike_ToSQL_Postgres(t *testing.T) {
 	t.Parallel()
 	sql, _, _ := ILike("name", "%test%").ToSQL(Postgres(), 1)
-	if sql != "name ILIKE $1" {
+	if sql != `"name" ILIKE $1` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -128,7 +128,7 @@ func TestILike_ToSQL_Postgres(t *testing.T) {
 func TestILike_ToSQL_MySQL(t *testing.T) {
 	t.Parallel()
 	sql, _, _ := ILike("name", "%test%").ToSQL(MySQL(), 1)
-	if sql != "name LIKE ?" {
+	if sql != "LOWER(`name`) LIKE LOWER(?)" {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -136,7 +136,7 @@ func TestILike_ToSQL_MySQL(t *testing.T) {
 func TestBetween_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, args, next := Between("age", 18, 65).ToSQL(pgDialect(), 1)
-	if sql != "age BETWEEN $1 AND $2" {
+	if sql != `"age" BETWEEN $1 AND $2` {
 		t.Errorf("got %q", sql)
 	}
 	if len(args) != 2 {
@@ -150,7 +150,7 @@ func TestBetween_ToSQL(t *testing.T) {
 func TestIsNull_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, args, next := IsNull("deleted_at").ToSQL(pgDialect(), 5)
-	if sql != "deleted_at IS NULL" {
+	if sql != `"deleted_at" IS NULL` {
 		t.Errorf("got %q", sql)
 	}
 	if len(args) != 0 {
@@ -164,7 +164,7 @@ func TestIsNull_ToSQL(t *testing.T) {
 func TestIsNotNull_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, _, _ := IsNotNull("deleted_at").ToSQL(pgDialect(), 1)
-	if sql != "deleted_at IS NOT NULL" {
+	if sql != `"deleted_at" IS NOT NULL` {
 		t.Errorf("got %q", sql)
 	}
 }
@@ -177,8 +177,8 @@ func TestAnd_ToSQL(t *testing.T) {
 		wantSQL string
 	}{
 		{"empty", nil, "TRUE"},
-		{"single", []Spec{Eq("a", 1)}, "a = $1"},
-		{"multiple", []Spec{Eq("a", 1), Eq("b", 2)}, "(a = $1) AND (b = $2)"},
+		{"single", []Spec{Eq("a", 1)}, `"a" = $1`},
+		{"multiple", []Spec{Eq("a", 1), Eq("b", 2)}, `("a" = $1) AND ("b" = $2)`},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -199,8 +199,8 @@ func TestOr_ToSQL(t *testing.T) {
 		wantSQL string
 	}{
 		{"empty", nil, "FALSE"},
-		{"single", []Spec{Eq("a", 1)}, "a = $1"},
-		{"multiple", []Spec{Eq("a", 1), Eq("b", 2)}, "(a = $1) OR (b = $2)"},
+		{"single", []Spec{Eq("a", 1)}, `"a" = $1`},
+		{"multiple", []Spec{Eq("a", 1), Eq("b", 2)}, `("a" = $1) OR ("b" = $2)`},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -216,7 +216,7 @@ func TestOr_ToSQL(t *testing.T) {
 func TestNot_ToSQL(t *testing.T) {
 	t.Parallel()
 	sql, args, next := Not(Eq("a", 1)).ToSQL(pgDialect(), 1)
-	if sql != "NOT (a = $1)" {
+	if sql != `NOT ("a" = $1)` {
 		t.Errorf("got %q", sql)
 	}
 	if len(args) != 1 {
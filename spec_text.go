@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+type textSearchSpec struct {
+	table   string
+	columns []string
+	query   string
+}
+
+func TextSearch(columns []string, query string) Spec {
+	return &textSearchSpec{columns: columns, query: query}
+}
+
+func (s *textSearchSpec) ToSQL(d Dialect, offset int) (string, []any, int) {
+	if strings.TrimSpace(s.query) == "" {
+		return "TRUE", nil, offset
+	}
+	var args []any
+	sql := textSearchSQL(d, s.quotedTable(d), s.columns, s.query, binder(d, offset, &args))
+	if sql == "" || len(s.columns) == 0 {
+		return "FALSE", nil, offset
+	}
+	return sql, args, offset + len(args)
+}
+
+func (s *textSearchSpec) rankSQL(d Dialect, bind func(any) string) string {
+	return textRankSQL(d, s.quotedTable(d), s.columns, s.query, bind)
+}
+
+func (s *textSearchSpec) quotedTable(d Dialect) string {
+	if s.table == "" {
+		return ""
+	}
+	return quoteIdent(d, s.table)
+}
+
+func (s *textSearchSpec) bind(table string) *textSearchSpec {
+	if s.table != "" {
+		return s
+	}
+	return &textSearchSpec{table: table, columns: s.columns, query: s.query}
+}
+
+func (s *textSearchSpec) validate() error {
+	if len(s.columns) == 0 {
+		return fmt.Errorf("%w: text search without columns", ErrInvalidSpec)
+	}
+	return nil
+}
+
+func (s *textSearchSpec) checkDialect(d Dialect) error {
+	bind := func(any) string { return "?" }
+	if textSearchSQL(d, s.quotedTable(d), s.columns, s.query, bind) == "" {
+		return fmt.Errorf("%w: text search is not supported by %T without a table", ErrUnsupportedSpec, d)
+	}
+	return nil
+}
+
+func (s *textSearchSpec) inspect() Node {
+	return Node{Kind: TextSearchNode, Op: "MATCH", Columns: s.columns, Values: []any{s.query}}
+}
+
+func (s *textSearchSpec) mapColumns(fn func(string) (string, error)) (Spec, error) {
+	cols := make([]string, len(s.columns))
+	for i, c := range s.columns {
+		col, err := fn(c)
+		if err != nil {
+			return nil, err
+		}
+		cols[i] = col
+	}
+	return &textSearchSpec{table: s.table, columns: cols, query: s.query}, nil
+}
+
+func bindTextSearch(s Spec, table string) (Spec, error) {
+	return transformSpec(s, func(n Spec) (Spec, error) {
+		if t, ok := n.(*textSearchSpec); ok {
+			return t.bind(table), nil
+		}
+		return n, nil
+	})
+}
+
+func fts5Query(columns []string, query string) string {
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		cols[i] = fts5String(c[strings.LastIndexByte(c, '.')+1:])
+	}
+	terms := strings.Fields(query)
+	for i, t := range terms {
+		terms[i] = fts5String(t)
+	}
+	return "{" + strings.Join(cols, " ") + "} : (" + strings.Join(terms, " ") + ")"
+}
+
+func fts5String(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTextSearch_ToSQL(t *testing.T) {
+	t.Parallel()
+	spec := TextSearch([]string{"title", "body"}, `go "sql`)
+	tests := []struct {
+		d    Dialect
+		want string
+		arg  any
+	}{
+		{Postgres(), `to_tsvector(coalesce("title", '') || ' ' || coalesce("body", '')) @@ plainto_tsquery($1)`, `go "sql`},
+		{MySQL(), "MATCH (`title`, `body`) AGAINST (? IN NATURAL LANGUAGE MODE)", `go "sql`},
+		{SQLite(), `"docs" MATCH ?`, `{"title" "body"} : ("go" """sql")`},
+	}
+	for _, tt := range tests {
+		sql, args, next := spec.(*textSearchSpec).bind("docs").ToSQL(tt.d, 1)
+		if sql != tt.want || !reflect.DeepEqual(args, []any{tt.arg}) || next != 2 {
+			t.Errorf("expected %s %v, got %s %v", tt.want, tt.arg, sql, args)
+		}
+	}
+	if sql, _, _ := TextSearch([]string{"title"}, "  ").ToSQL(Postgres(), 1); sql != "TRUE" {
+		t.Errorf("blank query must not filter, got %s", sql)
+	}
+	if err := validateDialect(spec, SQLite()); !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("unbound SQLite search: expected ErrUnsupportedSpec, got %v", err)
+	}
+	if err := Validate(TextSearch(nil, "go")); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec, got %v", err)
+	}
+	if got := Columns(And(Eq("lang", "en"), spec)); !reflect.DeepEqual(got, []string{"lang", "title", "body"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestQuery_OrderByRank(t *testing.T) {
+	t.Parallel()
+	r := &Repository[string]{table: simpleTable, dialect: Postgres()}
+	q := r.Query(context.Background()).
+		Where(Eq("status", "open")).
+		Where(TextSearch([]string{"title"}, "go")).
+		OrderByRank([]string{"title"}, "go").
+		Limit(10)
+	sql, args, err := buildQuerySQL(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `SELECT "id" FROM "items" WHERE ("status" = $1) AND (to_tsvector(coalesce("title", '')) @@ plainto_tsquery($2))` +
+		` ORDER BY ts_rank(to_tsvector(coalesce("title", '')), plainto_tsquery($3)) DESC LIMIT $4`
+	if sql != want || !reflect.DeepEqual(args, []any{"open", "go", "go", int64(10)}) {
+		t.Errorf("got %s %v", sql, args)
+	}
+
+	r.dialect = SQLite()
+	sql, _, err = buildQuerySQL(r.Query(context.Background()).
+		Where(TextSearch([]string{"title"}, "go")).OrderByRank([]string{"title"}, "go"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `SELECT "id" FROM "items" WHERE "items" MATCH ? ORDER BY -"items".rank DESC`; sql != want {
+		t.Errorf("got %s", sql)
+	}
+}
+
+func TestQuery_Page_OffsetCursorForRank(t *testing.T) {
+	t.Parallel()
+	rows := func(ids ...string) testQueryResult {
+		res := testQueryResult{columns: []string{"id"}}
+		for _, id := range ids {
+			res.rows = append(res.rows, []sqlDriver.Value{id})
+		}
+		return res
+	}
+	conn := &testConn{queries: []testQueryResult{rows("a", "b", "c"), rows("c"), rows("a", "b")}}
+	repo := newSimpleTestRepo(t, conn, simpleTable)
+	query := func() *Query[string] {
+		return repo.Query(context.Background()).OrderByRank([]string{"title"}, "go").PageSize(2)
+	}
+
+	first, err := query().Page(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(first.Items, []string{"a", "b"}) || !first.HasMore {
+		t.Fatalf("unexpected page: %+v", first)
+	}
+	if cur, _ := DecodeCursor(first.NextCursor); cur.Offset != 2 || cur.Values != nil {
+		t.Errorf("expected offset cursor, got %+v", cur)
+	}
+
+	second, err := query().After(first.NextCursor).Page(nil)
+	if err != nil || !reflect.DeepEqual(second.Items, []string{"c"}) || second.HasMore || second.NextCursor != "" {
+		t.Fatalf("unexpected page: %+v, %v", second, err)
+	}
+	if cur, _ := DecodeCursor(second.PrevCursor); !second.HasPrev || cur.Offset != 2 {
+		t.Errorf("expected previous cursor at offset 2, got %+v", second)
+	}
+
+	back, err := query().Before(pageCursor(query(), Cursor{Offset: 1})).Page(nil)
+	if err != nil || !reflect.DeepEqual(back.Items, []string{"a"}) || !back.HasMore {
+		t.Errorf("unexpected page: %+v, %v", back, err)
+	}
+}
+
+func TestMemory_RankOrderUnsupported(t *testing.T) {
+	t.Parallel()
+	m := newMemUsers(t, memUser{ID: 1, Name: "a"})
+	_, err := m.Query(context.Background()).OrderByRank([]string{"name"}, "a").All()
+	if !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "context"
+
+type Store[T any] interface {
+	Find(ctx context.Context, ids ...any) (T, error)
+	FindBy(ctx context.Context, s Spec) ([]T, error)
+	CountBy(ctx context.Context, s Spec) (int64, error)
+	ExistsBy(ctx context.Context, s Spec) (bool, error)
+	Save(ctx context.Context, aggregate T) error
+	Delete(ctx context.Context, ids ...any) error
+	Query(ctx context.Context) *Query[T]
+	NewQuery() *Query[T]
+}
+
+var (
+	_ Store[any] = (*Repository[any])(nil)
+	_ Store[any] = (*Memory[any])(nil)
+)
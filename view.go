@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type ViewConfig[T any] struct {
+	Name       string
+	SQL        string
+	Args       []any
+	Columns    []string
+	PrimaryKey []string
+	Aliases    map[string]string
+	Tables     map[string][]string
+	Scan       func(Scanner) (T, error)
+	Values     func(T) []any
+}
+
+type viewBase struct {
+	sql  string
+	args []any
+}
+
+type View[T any] struct {
+	repo *Repository[T]
+}
+
+func NewView[T any](db *sql.DB, dialect Dialect, cfg ViewConfig[T]) *View[T] {
+	name := cfg.Name
+	if name == "" {
+		name = "view"
+	}
+	table := Table{Name: name, PrimaryKey: cfg.PrimaryKey, Columns: cfg.Columns}
+	r := New(db, dialect, Simple(SimpleConfig[T]{Table: table, Scan: cfg.Scan, Values: cfg.Values}))
+	r.base = &viewBase{sql: cfg.SQL, args: cfg.Args}
+	r.columns = newColumnResolver(table, nil, ColumnWhitelist{Aliases: cfg.Aliases, Tables: cfg.Tables})
+	if cfg.Values == nil {
+		r.encode = nil
+	}
+	return &View[T]{repo: r}
+}
+
+func (v *View[T]) WithLogger(l Logger) *View[T] {
+	return &View[T]{repo: v.repo.WithLogger(l)}
+}
+
+func (v *View[T]) WithExecutor(wrap func(Executor) Executor) *View[T] {
+	return &View[T]{repo: v.repo.WithExecutor(wrap)}
+}
+
+func (v *View[T]) WithCursorKey(key []byte) *View[T] {
+	return &View[T]{repo: v.repo.WithCursorKey(key)}
+}
+
+func (v *View[T]) Query(ctx context.Context) *Query[T] { return v.repo.Query(ctx) }
+
+func (v *View[T]) NewQuery() *Query[T] { return v.repo.NewQuery() }
+
+func (v *View[T]) Find(ctx context.Context, ids ...any) (T, error) { return v.repo.Find(ctx, ids...) }
+
+func (v *View[T]) FindBy(ctx context.Context, s Spec) ([]T, error) { return v.repo.FindBy(ctx, s) }
+
+func (v *View[T]) CountBy(ctx context.Context, s Spec) (int64, error) { return v.repo.CountBy(ctx, s) }
+
+func (v *View[T]) ExistsBy(ctx context.Context, s Spec) (bool, error) { return v.repo.ExistsBy(ctx, s) }
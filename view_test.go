@@ -0,0 +1,147 @@
+package repository_test
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/shuldan/repository"
+	"github.com/shuldan/repository/repositorytest"
+)
+
+type postRow struct {
+	ID     int64
+	Title  string
+	Author string
+}
+
+const postsViewFrom = `FROM (SELECT p.id, p.title, u.name AS author FROM posts p` +
+	` JOIN users u ON u.id = p.author_id WHERE p.tenant_id = $1) AS "posts_view"`
+
+func scanPost(sc repository.Scanner) (postRow, error) {
+	var p postRow
+	return p, sc.Scan(&p.ID, &p.Title, &p.Author)
+}
+
+func newPostView(
+	t *testing.T, conn *repositorytest.Conn,
+) (*repository.View[postRow], *repositorytest.Recorder) {
+	t.Helper()
+	rec := repositorytest.NewRecorder(nil)
+	v := repository.NewView(repositorytest.NewDB(t, conn), repository.Postgres(), repository.ViewConfig[postRow]{
+		Name: "posts_view",
+		SQL: `SELECT p.id, p.title, u.name AS author FROM posts p JOIN users u ON u.id = p.author_id` +
+			` WHERE p.tenant_id = $1`,
+		Args:       []any{"t1"},
+		Columns:    []string{"id", "title", "author"},
+		PrimaryKey: []string{"id"},
+		Aliases:    map[string]string{"writer": "author"},
+		Scan:       scanPost,
+		Values:     func(p postRow) []any { return []any{p.ID, p.Title, p.Author} },
+	}).WithExecutor(rec.Wrap)
+	return v, rec
+}
+
+func TestView_Page(t *testing.T) {
+	t.Parallel()
+	conn := &repositorytest.Conn{Queries: []repositorytest.QueryResult{
+		{Columns: []string{"id", "title", "author"}, Rows: [][]sqlDriver.Value{
+			{int64(1), "a", "ann"}, {int64(2), "b", "bob"},
+		}},
+	}}
+	v, rec := newPostView(t, conn)
+	page, err := v.Query(context.Background()).
+		Where(repository.Eq("writer", "ann")).
+		OrderBy("title", repository.Asc).
+		PageSize(1).
+		Page()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := repositorytest.Call{
+		Kind: repositorytest.Query,
+		SQL: `SELECT "id", "title", "author" ` + postsViewFrom +
+			` WHERE "author" = $2 ORDER BY "title" ASC, "id" ASC LIMIT $3`,
+		Args: []any{"t1", "ann", int64(2)},
+	}
+	if calls := rec.Calls(); len(calls) != 1 || !reflect.DeepEqual(calls[0], want) {
+		t.Errorf("got %v", calls)
+	}
+	cur, err := repository.DecodeCursor(page.NextCursor)
+	if err != nil || !reflect.DeepEqual(cur.Values, map[string]any{"title": "a", "id": int64(1)}) {
+		t.Errorf("unexpected cursor: %v, %v", cur.Values, err)
+	}
+}
+
+func TestView_Find(t *testing.T) {
+	t.Parallel()
+	conn := &repositorytest.Conn{Queries: []repositorytest.QueryResult{
+		{Columns: []string{"id", "title", "author"}, Rows: [][]sqlDriver.Value{{int64(7), "a", "ann"}}},
+		{Columns: []string{"id", "title", "author"}},
+	}}
+	v, rec := newPostView(t, conn)
+	ctx := context.Background()
+	post, err := v.Find(ctx, int64(7))
+	if err != nil || post != (postRow{7, "a", "ann"}) {
+		t.Fatalf("got %+v, %v", post, err)
+	}
+	want := repositorytest.Call{
+		Kind: repositorytest.QueryRow,
+		SQL:  `SELECT "id", "title", "author" ` + postsViewFrom + ` WHERE "id" = $2`,
+		Args: []any{"t1", int64(7)},
+	}
+	if calls := rec.Calls(); len(calls) != 1 || !reflect.DeepEqual(calls[0], want) {
+		t.Errorf("got %v", calls)
+	}
+	if _, err := v.Find(ctx, int64(8)); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestView_Count(t *testing.T) {
+	t.Parallel()
+	conn := &repositorytest.Conn{Queries: []repositorytest.QueryResult{
+		{Columns: []string{"count"}, Rows: [][]sqlDriver.Value{{int64(3)}}},
+	}}
+	v, rec := newPostView(t, conn)
+	n, err := v.CountBy(context.Background(), repository.Like("title", "go%"))
+	if err != nil || n != 3 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	want := `SELECT COUNT(*) ` + postsViewFrom + ` WHERE "title" LIKE $2`
+	if calls := rec.Calls(); len(calls) != 1 || calls[0].SQL != want {
+		t.Errorf("got %v", calls)
+	}
+}
+
+func TestView_RejectsUnknownColumns(t *testing.T) {
+	t.Parallel()
+	v, _ := newPostView(t, &repositorytest.Conn{})
+	_, err := v.FindBy(context.Background(), repository.Eq("tenant_id", "t2"))
+	if !errors.Is(err, repository.ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestView_SQLiteTextSearchNeedsTable(t *testing.T) {
+	t.Parallel()
+	db := repositorytest.NewDB(t, &repositorytest.Conn{})
+	v := repository.NewView(db, repository.SQLite(), repository.ViewConfig[postRow]{
+		Name:       "posts_view",
+		SQL:        `SELECT id, title, author FROM posts_fts`,
+		Columns:    []string{"id", "title", "author"},
+		PrimaryKey: []string{"id"},
+		Scan:       scanPost,
+	})
+	ctx := context.Background()
+	search := repository.TextSearch([]string{"title"}, "go")
+	if _, err := v.FindBy(ctx, search); !errors.Is(err, repository.ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+	_, err := v.Query(ctx).OrderByRank([]string{"title"}, "go").All()
+	if !errors.Is(err, repository.ErrUnsupportedSpec) {
+		t.Errorf("expected ErrUnsupportedSpec, got %v", err)
+	}
+}